@@ -0,0 +1,98 @@
+package withings
+
+import (
+	"sort"
+	"time"
+)
+
+// ActivityDiff lists the days of an activity response that differ from a
+// previous response, as returned by ActivitiesMeasuresResp.Diff.
+type ActivityDiff struct {
+	// New are the days that weren't in the previous response.
+	New []Activity
+	// Updated are the days whose metrics changed since the previous
+	// response.
+	Updated []Activity
+}
+
+// Diff compares the activities of the response with those of previous, by
+// date, and returns the days that are new or whose metrics changed, in the
+// order they appear in the response. Days only in previous are ignored.
+func (r ActivitiesMeasuresResp) Diff(previous ActivitiesMeasuresResp) ActivityDiff {
+	var diff ActivityDiff
+	if r.Body == nil {
+		return diff
+	}
+
+	before := map[string]Activity{}
+	if previous.Body != nil {
+		for _, a := range previous.Body.Activities {
+			before[a.Date] = a
+		}
+	}
+
+	for _, a := range r.Body.Activities {
+		prev, ok := before[a.Date]
+		switch {
+		case !ok:
+			diff.New = append(diff.New, a)
+		case !a.sameMetrics(prev):
+			diff.Updated = append(diff.Updated, a)
+		}
+	}
+
+	return diff
+}
+
+// sameMetrics reports whether a and b hold the same metric values.
+func (a Activity) sameMetrics(b Activity) bool {
+	return a.Steps == b.Steps &&
+		a.Distance == b.Distance &&
+		a.Calories == b.Calories &&
+		a.Elevation == b.Elevation &&
+		a.Soft == b.Soft &&
+		a.Moderate == b.Moderate &&
+		a.Intense == b.Intense
+}
+
+// FillGaps returns a copy of the response with a zero-valued Activity added
+// for every day from start through end that has none, such as for charting a
+// continuous series. Days are the calendar days of start and end in the
+// location of start, which should be the user's timezone so that they match
+// the dates reported by the API. The activities are sorted by date; any
+// outside the range are kept.
+func (r ActivitiesMeasuresResp) FillGaps(start, end time.Time) ActivitiesMeasuresResp {
+	loc := start.Location()
+	end = end.In(loc)
+
+	body := ActivitiesMeasuresRespBody{}
+	if r.Body != nil {
+		body = *r.Body
+	}
+
+	have := map[string]bool{}
+	for _, a := range body.Activities {
+		have[a.Date] = true
+	}
+	activities := append([]Activity(nil), body.Activities...)
+
+	// Walk the dates in UTC, where every day is 24 hours long.
+	y, m, d := start.Date()
+	ey, em, ed := end.Date()
+	last := time.Date(ey, em, ed, 0, 0, 0, 0, time.UTC)
+	for day := time.Date(y, m, d, 0, 0, 0, 0, time.UTC); !day.After(last); day = day.AddDate(0, 0, 1) {
+		date := day.Format("2006-01-02")
+		if have[date] {
+			continue
+		}
+		parsed := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, loc)
+		activities = append(activities, Activity{ParsedDate: &parsed, Date: date, TimeZone: loc.String()})
+	}
+
+	sort.SliceStable(activities, func(i, j int) bool {
+		return activities[i].Date < activities[j].Date
+	})
+	body.Activities = activities
+	r.Body = &body
+	return r
+}
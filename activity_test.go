@@ -0,0 +1,96 @@
+package withings
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestActivityDiff(t *testing.T) {
+	previous := ActivitiesMeasuresResp{Body: &ActivitiesMeasuresRespBody{Activities: []Activity{
+		{Date: "2021-01-01", Steps: 8000, Calories: 300},
+		{Date: "2021-01-02", Steps: 4000, Calories: 150},
+		{Date: "2020-12-31", Steps: 100},
+	}}}
+	current := ActivitiesMeasuresResp{Body: &ActivitiesMeasuresRespBody{Activities: []Activity{
+		{Date: "2021-01-01", Steps: 8000, Calories: 300},
+		{Date: "2021-01-02", Steps: 9000, Calories: 350},
+		{Date: "2021-01-03", Steps: 1200, Calories: 40},
+	}}}
+
+	diff := current.Diff(previous)
+	require.Len(t, diff.New, 1)
+	require.Equal(t, "2021-01-03", diff.New[0].Date)
+	require.Len(t, diff.Updated, 1)
+	require.Equal(t, "2021-01-02", diff.Updated[0].Date)
+	require.EqualValues(t, 9000, diff.Updated[0].Steps)
+
+	require.Len(t, current.Diff(ActivitiesMeasuresResp{}).New, 3)
+	require.Empty(t, current.Diff(current).New)
+	require.Empty(t, current.Diff(current).Updated)
+	require.Equal(t, ActivityDiff{}, ActivitiesMeasuresResp{}.Diff(previous))
+}
+
+func TestActivityFillGaps(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	resp := ActivitiesMeasuresResp{Body: &ActivitiesMeasuresRespBody{Activities: []Activity{
+		{Date: "2021-03-16", Steps: 5000},
+		{Date: "2021-03-13", Steps: 8000},
+	}}}
+
+	// Late evening in New York is already the next day in UTC, and the range
+	// spans the DST change of 2021-03-14.
+	start := time.Date(2021, 3, 12, 22, 0, 0, 0, loc)
+	end := time.Date(2021, 3, 16, 23, 0, 0, 0, loc)
+	filled := resp.FillGaps(start, end.UTC())
+
+	var dates []string
+	for _, a := range filled.Body.Activities {
+		dates = append(dates, a.Date)
+	}
+	require.Equal(t, []string{"2021-03-12", "2021-03-13", "2021-03-14", "2021-03-15", "2021-03-16"}, dates)
+	require.Equal(t, float64(8000), filled.Body.Activities[1].Steps)
+	require.Zero(t, filled.Body.Activities[2].Steps)
+	require.Equal(t, "America/New_York", filled.Body.Activities[2].TimeZone)
+	require.Equal(t, time.Date(2021, 3, 12, 0, 0, 0, 0, loc), *filled.Body.Activities[0].ParsedDate)
+	require.Equal(t, time.Date(2021, 3, 14, 0, 0, 0, 0, loc), *filled.Body.Activities[2].ParsedDate)
+	require.Len(t, resp.Body.Activities, 2)
+
+	empty := ActivitiesMeasuresResp{}.FillGaps(start, start)
+	require.Len(t, empty.Body.Activities, 1)
+}
+
+func TestActivityDefaultEndIncludesToday(t *testing.T) {
+	// A user in the timezone furthest ahead of UTC, whose today may already
+	// be tomorrow wherever the server runs.
+	kiritimati, err := time.LoadLocation("Pacific/Kiritimati")
+	require.NoError(t, err)
+	today := time.Now().In(kiritimati).Format("2006-01-02")
+
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		require.NoError(t, req.ParseForm())
+		if req.Form.Get("enddateymd") < today {
+			fmt.Fprint(rw, `{"status":0,"body":{"activity":[]}}`)
+			return
+		}
+		fmt.Fprintf(rw, `{"status":0,"body":{"activity":[{"date":%q,"steps":1234,"timezone":"Pacific/Kiritimati"}]}}`, today)
+	})
+
+	for _, params := range []*ActivityMeasuresQueryParam{nil, {}} {
+		r, err := u.GetActivityMeasures(params)
+		require.NoError(t, err)
+		require.Len(t, r.Body.Activities, 1)
+		require.Equal(t, today, r.Body.Activities[0].Date)
+	}
+
+	// With the user's timezone configured, that is used instead.
+	u.Client.DefaultTimezone = kiritimati
+	r, err := u.GetActivityMeasures(nil)
+	require.NoError(t, err)
+	require.Len(t, r.Body.Activities, 1)
+}
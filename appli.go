@@ -0,0 +1,48 @@
+package withings
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Appli codes identify the kind of data a notification is about, as sent to
+// the callback and used by CreateNotification.
+const (
+	AppliWeight      = 1  // weight and body composition
+	AppliTemperature = 2  // temperature
+	AppliHeart       = 4  // blood pressure, heart rate and SpO2
+	AppliActivity    = 16 // steps, distance, calories and workouts
+	AppliSleep       = 44 // sleep
+	AppliUserAction  = 46 // actions on the user account
+	AppliBedIn       = 50 // user got into bed
+	AppliBedOut      = 51 // user got out of bed
+	AppliInflateDone = 52 // sleep sensor inflation done
+	AppliECG         = 54 // ECG data
+	AppliECGFailed   = 55 // ECG measure failed
+	AppliGlucose     = 58 // glucose data
+)
+
+// FetchAppli retrieves the data a notification for appli says has changed
+// between start and end, so webhook handlers don't need their own mapping from
+// appli codes to methods. Measure applis return a BodyMeasuresResp, activity a
+// ActivitiesMeasuresResp, sleep a SleepSummaryResp and ECG a HeartListResp.
+// Applis that carry no data retrievable by this package return
+// ErrUnsupportedAppli.
+func (u *User) FetchAppli(ctx context.Context, appli int, start, end time.Time) (Response, error) {
+	switch appli {
+	case AppliWeight, AppliTemperature, AppliHeart, AppliGlucose:
+		r, err := u.GetBodyMeasuresCtx(ctx, &BodyMeasuresQueryParams{StartDate: &start, EndDate: &end})
+		return r, err
+	case AppliActivity:
+		r, err := u.GetActivityMeasuresCtx(ctx, &ActivityMeasuresQueryParam{StartDateYMD: &start, EndDateYMD: &end})
+		return r, err
+	case AppliSleep:
+		r, err := u.GetSleepSummaryCtx(ctx, &SleepSummaryQueryParam{StartDateYMD: &start, EndDateYMD: &end})
+		return r, err
+	case AppliECG:
+		r, err := u.GetHeartListCtx(ctx, &HeartListQueryParam{StartDate: &start, EndDate: &end})
+		return r, err
+	}
+	return nil, fmt.Errorf("fetching appli %d: %w", appli, ErrUnsupportedAppli)
+}
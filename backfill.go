@@ -0,0 +1,131 @@
+package withings
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Category is a kind of data imported by Backfill.
+type Category string
+
+// Categories of data Backfill can import.
+const (
+	CategoryBodyMeasures Category = "measures"
+	CategoryActivity     Category = "activity"
+	CategoryWorkouts     Category = "workouts"
+	CategorySleepSummary Category = "sleepsummary"
+)
+
+// backfillChunkDays is the number of days requested at once by Backfill.
+const backfillChunkDays = 30
+
+// BackfillData is the data imported by Backfill.
+type BackfillData struct {
+	MeasureGroups  []MeasureGroup
+	Activities     []Activity
+	Workouts       []Workout
+	SleepSummaries []SleepSummary
+}
+
+// backfillFetch retrieves the data of one category between start and end,
+// both at midnight, and appends it to data.
+type backfillFetch func(u *User, ctx context.Context, start, end time.Time, data *BackfillData) error
+
+var backfillFetchers = map[Category]backfillFetch{
+	CategoryBodyMeasures: backfillBodyMeasures,
+	CategoryActivity:     backfillActivity,
+	CategoryWorkouts:     backfillWorkouts,
+	CategorySleepSummary: backfillSleepSummary,
+}
+
+// Backfill imports all of the user's data of the given categories from since
+// until now, such as for the initial import of a new user. Each category is
+// walked in turn, in chunks of 30 days starting at midnight of since, and
+// progress, if not nil, is called after each chunk with the category and the
+// time up to which its data has been imported.
+//
+// If an error occurs, the data imported so far is returned with it. The import
+// can be resumed by calling Backfill again with the remaining categories and
+// the last time reported to progress; as chunks start at midnight, at most the
+// last partial day is imported again.
+func (u *User) Backfill(ctx context.Context, categories []Category, since time.Time, progress func(Category, time.Time)) (*BackfillData, error) {
+	data := &BackfillData{}
+	ctx = WithRequestOptions(ctx, noDataAsError(false), paging())
+	now := u.Client.now()
+
+	y, m, d := since.Date()
+	since = time.Date(y, m, d, 0, 0, 0, 0, since.Location())
+
+	for _, c := range categories {
+		fetch, ok := backfillFetchers[c]
+		if !ok {
+			return data, fmt.Errorf("backfill: unknown category %q", c)
+		}
+
+		for start := since; start.Before(now); {
+			end := start.AddDate(0, 0, backfillChunkDays)
+			if err := fetch(u, ctx, start, end, data); err != nil {
+				return data, fmt.Errorf("backfilling %s from %s: %w", c, start.Format("2006-01-02"), err)
+			}
+
+			if end.After(now) {
+				end = now
+			}
+			if progress != nil {
+				progress(c, end)
+			}
+			start = end
+		}
+	}
+
+	return data, nil
+}
+
+func backfillBodyMeasures(u *User, ctx context.Context, start, end time.Time, data *BackfillData) error {
+	last := end.Add(-time.Second)
+	r, err := u.GetAllBodyMeasuresCtx(ctx, &BodyMeasuresQueryParams{StartDate: &start, EndDate: &last})
+	if err != nil {
+		return err
+	}
+	data.MeasureGroups = append(data.MeasureGroups, r.Body.MeasureGrps...)
+	return nil
+}
+
+func backfillActivity(u *User, ctx context.Context, start, end time.Time, data *BackfillData) error {
+	last := end.AddDate(0, 0, -1)
+	p := &ActivityMeasuresQueryParam{StartDateYMD: &start, EndDateYMD: &last}
+	return followOffsets(nil, func(offset *int) (bool, int, error) {
+		p.Offset = offset
+		r, err := u.GetActivityMeasuresCtx(ctx, p)
+		if err != nil {
+			return false, 0, err
+		}
+		data.Activities = append(data.Activities, r.Body.Activities...)
+		return r.Body.More, r.Body.Offset, nil
+	})
+}
+
+func backfillWorkouts(u *User, ctx context.Context, start, end time.Time, data *BackfillData) error {
+	last := end.AddDate(0, 0, -1)
+	p := &WorkoutsQueryParam{StartDateYMD: &start, EndDateYMD: &last}
+	return followOffsets(nil, func(offset *int) (bool, int, error) {
+		p.Offset = offset
+		r, err := u.GetWorkoutsCtx(ctx, p)
+		if err != nil {
+			return false, 0, err
+		}
+		data.Workouts = append(data.Workouts, r.Body.Series...)
+		return r.Body.More, r.Body.Offset, nil
+	})
+}
+
+func backfillSleepSummary(u *User, ctx context.Context, start, end time.Time, data *BackfillData) error {
+	last := end.AddDate(0, 0, -1)
+	r, err := u.GetAllSleepSummaryCtx(ctx, &SleepSummaryQueryParam{StartDateYMD: &start, EndDateYMD: &last})
+	if err != nil {
+		return err
+	}
+	data.SleepSummaries = append(data.SleepSummaries, r.Body.Series...)
+	return nil
+}
@@ -0,0 +1,108 @@
+package withings
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackfill(t *testing.T) {
+	var ranges []string
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		require.NoError(t, req.ParseForm())
+		q := req.Form
+		switch q.Get("action") {
+		case "getmeas":
+			ranges = append(ranges, q.Get("startdate")+"-"+q.Get("enddate"))
+			fmt.Fprint(rw, `{"status":0,"body":{"measuregrps":[{"grpid":1,"date":1636300800,"measures":[]}]}}`)
+		case "getactivity":
+			ranges = append(ranges, q.Get("startdateymd")+"/"+q.Get("enddateymd"))
+			fmt.Fprint(rw, `{"status":0,"body":{"activity":[{"date":"2021-01-02"}]}}`)
+		case "getworkouts":
+			fmt.Fprint(rw, `{"status":2555,"error":"unknown"}`)
+		default:
+			t.Errorf("unexpected action %q", q.Get("action"))
+		}
+	})
+
+	since := time.Now().AddDate(0, 0, -45)
+	midnight := time.Date(since.Year(), since.Month(), since.Day(), 0, 0, 0, 0, since.Location())
+
+	var reported []time.Time
+	progress := func(c Category, at time.Time) {
+		reported = append(reported, at)
+	}
+	data, err := u.Backfill(context.Background(), []Category{CategoryBodyMeasures, CategoryActivity}, since, progress)
+	require.NoError(t, err)
+	require.Len(t, data.MeasureGroups, 2)
+	require.Len(t, data.Activities, 2)
+
+	second := midnight.AddDate(0, 0, 30)
+	require.Equal(t, []string{
+		fmt.Sprintf("%d-%d", midnight.Unix(), second.Unix()-1),
+		fmt.Sprintf("%d-%d", second.Unix(), second.AddDate(0, 0, 30).Unix()-1),
+		midnight.Format("2006-01-02") + "/" + second.AddDate(0, 0, -1).Format("2006-01-02"),
+		second.Format("2006-01-02") + "/" + second.AddDate(0, 0, 29).Format("2006-01-02"),
+	}, ranges)
+
+	require.Len(t, reported, 4)
+	require.Equal(t, second, reported[0])
+	require.WithinDuration(t, time.Now(), reported[1], time.Minute)
+
+	data, err = u.Backfill(context.Background(), []Category{CategoryWorkouts}, reported[1], progress)
+	require.Error(t, err)
+	require.NotNil(t, data)
+	require.Len(t, reported, 4)
+
+	_, err = u.Backfill(context.Background(), []Category{"unknown"}, since, nil)
+	require.Error(t, err)
+}
+
+func TestBackfillUsesClientClock(t *testing.T) {
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(rw, `{"status":0,"body":{"activity":[]}}`)
+	})
+	atomic.StoreInt64(&u.Client.state.skew, int64(-10*24*time.Hour))
+
+	var reported []time.Time
+	_, err := u.Backfill(context.Background(), []Category{CategoryActivity}, time.Now().AddDate(0, 0, -15), func(c Category, at time.Time) {
+		reported = append(reported, at)
+	})
+	require.NoError(t, err)
+	require.Len(t, reported, 1)
+	require.WithinDuration(t, time.Now().Add(-10*24*time.Hour), reported[0], time.Minute)
+}
+
+func TestBackfillFollowsWorkoutPages(t *testing.T) {
+	var offsets []string
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		require.NoError(t, req.ParseForm())
+		offsets = append(offsets, req.Form.Get("offset"))
+		switch req.Form.Get("offset") {
+		case "":
+			fmt.Fprint(rw, `{"status":0,"body":{"series":[{"id":1,"date":"2021-01-01"}],"more":true,"offset":1}}`)
+		default:
+			fmt.Fprint(rw, `{"status":0,"body":{"series":[{"id":2,"date":"2021-01-02"}],"more":false,"offset":0}}`)
+		}
+	})
+
+	data, err := u.Backfill(context.Background(), []Category{CategoryWorkouts}, time.Now().AddDate(0, 0, -1), nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"", "1"}, offsets)
+	require.Len(t, data.Workouts, 2)
+}
+
+func TestBackfillStalledWorkoutOffset(t *testing.T) {
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(rw, `{"status":0,"body":{"series":[{"id":1,"date":"2021-01-01"}],"more":true,"offset":1}}`)
+	})
+
+	_, err := u.Backfill(context.Background(), []Category{CategoryWorkouts}, time.Now().AddDate(0, 0, -1), nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "did not advance the offset")
+}
@@ -0,0 +1,147 @@
+package withings
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/asymmetricia/withings/enum/status"
+)
+
+// defaultRateLimitBackoff is how long a user's requests are paused after they
+// are rate limited, if the response doesn't say how long to wait.
+const defaultRateLimitBackoff = time.Minute
+
+// backoffGate pauses all of a user's requests once one of them is rate
+// limited, so concurrent calls back off together instead of each running
+// into the limit. The zero value is ready to use.
+type backoffGate struct {
+	mu    sync.Mutex
+	until time.Time
+}
+
+// wait blocks until the gate is open, or returns an error if ctx is done
+// first.
+func (g *backoffGate) wait(ctx context.Context) error {
+	g.mu.Lock()
+	d := time.Until(g.until)
+	g.mu.Unlock()
+	if d <= 0 {
+		return nil
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// pause closes the gate for d, unless it is already closed for longer.
+func (g *backoffGate) pause(d time.Duration) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if until := time.Now().Add(d); until.After(g.until) {
+		g.until = until
+	}
+}
+
+// defaultBackoffBase is the first retry delay of rate limited requests when
+// Client.BackoffBase is zero.
+const defaultBackoffBase = time.Second
+
+// rateLimited reports whether a response was rate limited, either with an HTTP
+// 429 or with a successful HTTP response whose body carries the API's
+// TooManyRequets status.
+func rateLimited(res *http.Response, body []byte) bool {
+	if res.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return false
+	}
+	var envelope struct {
+		Status status.Status `json:"status"`
+	}
+	return json.Unmarshal(body, &envelope) == nil && envelope.Status == status.TooManyRequets
+}
+
+// rateLimitBackoff returns how long to pause the user's requests after a rate
+// limited response: as long as its Retry-After header asks, or else the
+// exponential backoff of the policy for the attempt if the request is retried,
+// or the policy's pause if it isn't.
+func rateLimitBackoff(h http.Header, p retryPolicy, attempt int, retry bool) time.Duration {
+	if d, ok := retryAfter(h.Get("Retry-After")); ok {
+		return d
+	}
+	if !retry {
+		return p.pause
+	}
+
+	base := p.base
+	if base <= 0 {
+		base = defaultBackoffBase
+	}
+	return base << attempt
+}
+
+// retryAfter parses a Retry-After header, given either in seconds or as an
+// HTTP date, reporting whether it held either.
+func retryAfter(header string) (time.Duration, bool) {
+	if s, err := strconv.Atoi(header); err == nil && s >= 0 {
+		return time.Duration(s) * time.Second, true
+	}
+	if t, err := http.ParseTime(header); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// RateLimit is the rate limit state reported by the headers of a response, so
+// callers can slow down before they are rate limited.
+type RateLimit struct {
+	// Remaining is the number of requests left in the current window.
+	Remaining int
+	// Reset is when the current window ends and the quota is restored. It is
+	// zero if the response didn't say.
+	Reset time.Time
+}
+
+// parseRateLimit reads the X-RateLimit-Remaining and X-RateLimit-Reset
+// headers, or their unprefixed RateLimit-* equivalents, returning nil if
+// the response has no remaining quota header. The reset is accepted either as
+// a Unix time or as a number of seconds from now.
+func parseRateLimit(h http.Header, now time.Time) *RateLimit {
+	header := func(name string) string {
+		if v := h.Get("X-" + name); v != "" {
+			return v
+		}
+		return h.Get(name)
+	}
+
+	remaining, err := strconv.Atoi(strings.TrimSpace(header("RateLimit-Remaining")))
+	if err != nil {
+		return nil
+	}
+
+	rl := &RateLimit{Remaining: remaining}
+	if reset, err := strconv.ParseInt(strings.TrimSpace(header("RateLimit-Reset")), 10, 64); err == nil && reset >= 0 {
+		// A Unix time this small would be in 1970, so it must be relative.
+		if reset < 1e9 {
+			rl.Reset = now.Add(time.Duration(reset) * time.Second)
+		} else {
+			rl.Reset = time.Unix(reset, 0)
+		}
+	}
+	return rl
+}
@@ -0,0 +1,91 @@
+package withings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// CheckCallbackReachable checks that callbackURL responds before it is used
+// with CreateNotification, as Withings silently drops subscriptions to
+// callbacks it can't reach. It sends a HEAD request, falling back to GET if the
+// server doesn't allow HEAD, and returns an error describing the failure
+// unless the callback answers with a 2XX status. The check is made from this
+// host, so it catches DNS and firewall problems but not rules that only block
+// Withings. The user's token is never sent to the callback.
+func (u *User) CheckCallbackReachable(ctx context.Context, callbackURL url.URL) error {
+	transport := u.Client.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	hc := &http.Client{Transport: transport}
+
+	status := 0
+	for _, method := range []string{"HEAD", "GET"} {
+		req, err := http.NewRequestWithContext(ctx, method, callbackURL.String(), nil)
+		if err != nil {
+			return fmt.Errorf("checking callback %s: %w", callbackURL.String(), err)
+		}
+
+		res, err := hc.Do(req)
+		if err != nil {
+			return fmt.Errorf("checking callback %s: unreachable: %w", callbackURL.String(), err)
+		}
+		res.Body.Close()
+
+		status = res.StatusCode
+		if status != http.StatusMethodNotAllowed && status != http.StatusNotImplemented {
+			break
+		}
+	}
+
+	if status < 200 || status > 299 {
+		return fmt.Errorf("checking callback %s: responded with HTTP status %d", callbackURL.String(), status)
+	}
+	return nil
+}
+
+// SubscribeReliably subscribes to notifications as per CreateNotificationCtx,
+// after checking that the callback uses HTTPS and is reachable, and then
+// confirms with GetNotificationInformationCtx that Withings actually recorded
+// the subscription. If it didn't, the subscription is attempted once more
+// before giving up.
+func (u *User) SubscribeReliably(ctx context.Context, params *CreateNotificationParam) (CreateNotificationResp, error) {
+	if params == nil {
+		return CreateNotificationResp{}, errors.New("subscribing: no notification params")
+	}
+	if params.CallbackURL.Scheme != "https" {
+		return CreateNotificationResp{}, fmt.Errorf("subscribing: callback %s doesn't use https", params.CallbackURL.String())
+	}
+	if err := u.CheckCallbackReachable(ctx, params.CallbackURL); err != nil {
+		return CreateNotificationResp{}, fmt.Errorf("subscribing: %w", err)
+	}
+
+	var confirmErr error
+	for attempt := 0; attempt < 2; attempt++ {
+		resp, err := u.CreateNotificationCtx(ctx, params)
+		if err != nil {
+			return resp, fmt.Errorf("subscribing: %w", err)
+		}
+
+		if confirmErr = u.confirmSubscription(ctx, params); confirmErr == nil {
+			return resp, nil
+		}
+	}
+	return CreateNotificationResp{}, fmt.Errorf("subscribing: %w", confirmErr)
+}
+
+// confirmSubscription checks that the subscription described by params exists.
+func (u *User) confirmSubscription(ctx context.Context, params *CreateNotificationParam) error {
+	appli := params.Appli
+	info, err := u.GetNotificationInformationCtx(ctx, &NotificationInfoParam{CallbackURL: params.CallbackURL, Appli: &appli})
+	if err != nil {
+		return fmt.Errorf("confirming subscription: %w", err)
+	}
+	if info.Body == nil || info.Body.CallbackURL != params.CallbackURL.String() || info.Body.Appli != params.Appli {
+		return fmt.Errorf("confirming subscription: no subscription of %s to appli %d", params.CallbackURL.String(), params.Appli)
+	}
+	return nil
+}
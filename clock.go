@@ -0,0 +1,71 @@
+package withings
+
+import (
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// ClockSkewThreshold is the difference between the local clock and the Date
+// header of API responses above which the difference is recorded as clock
+// skew. Smaller differences are within the precision of the header, which has
+// a resolution of one second, and of network latency.
+const ClockSkewThreshold = 5 * time.Second
+
+// ClockSkew returns how far the API's clock is estimated to be ahead of the
+// local clock, as observed on the most recent response sent by the API itself
+// rather than by a cache. It is zero until such a response has been received,
+// and while the difference is below ClockSkewThreshold. A large value means
+// the local clock is misconfigured; token expiry is computed against the
+// API's clock to compensate.
+func (c *Client) ClockSkew() time.Duration {
+	if c.state == nil {
+		return 0
+	}
+	return time.Duration(atomic.LoadInt64(&c.state.skew))
+}
+
+// now returns the current time according to the API's clock.
+func (c *Client) now() time.Time {
+	return time.Now().Add(c.ClockSkew())
+}
+
+// skewTransport records the clock skew observed on every response that comes
+// straight from the API.
+type skewTransport struct {
+	next  http.RoundTripper
+	state *clientState
+}
+
+func (t skewTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	res, err := t.next.RoundTrip(req)
+	if err != nil {
+		return res, err
+	}
+
+	if !fromOrigin(res) {
+		return res, nil
+	}
+	if date, err := http.ParseTime(res.Header.Get("Date")); err == nil {
+		skew := date.Sub(time.Now())
+		if skew > -ClockSkewThreshold && skew < ClockSkewThreshold {
+			skew = 0
+		}
+		atomic.StoreInt64(&t.state.skew, int64(skew))
+	}
+
+	return res, nil
+}
+
+// fromOrigin reports whether res was sent by the API just now, rather than
+// served by a cache, whose Date header is that of the stored response. Caches
+// in the client's Transport mark their responses with X-From-Cache, and shared
+// caches on the way add an Age.
+func fromOrigin(res *http.Response) bool {
+	if res.Header.Get("X-From-Cache") == "1" {
+		return false
+	}
+	age, err := strconv.Atoi(res.Header.Get("Age"))
+	return err != nil || age <= 0
+}
@@ -0,0 +1,46 @@
+package withings
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClockSkew(t *testing.T) {
+	offset := 2 * time.Hour
+	var cached http.Header
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Date", time.Now().Add(offset).UTC().Format(http.TimeFormat))
+		for k, v := range cached {
+			rw.Header()[k] = v
+		}
+		fmt.Fprint(rw, `{"status":0,"body":{}}`)
+	})
+	require.Zero(t, u.Client.ClockSkew())
+
+	_, err := u.GetBodyMeasures(nil)
+	require.NoError(t, err)
+	require.InDelta(t, float64(2*time.Hour), float64(u.Client.ClockSkew()), float64(2*time.Second))
+
+	// The token, valid for another hour locally, has expired by the API's clock.
+	require.True(t, u.OauthToken.Expiry.Before(u.Client.now()))
+
+	// Differences within the threshold are not skew.
+	u.OauthToken.Expiry = time.Now().Add(3 * time.Hour)
+	offset = time.Second
+	_, err = u.GetBodyMeasures(nil)
+	require.NoError(t, err)
+	require.Zero(t, u.Client.ClockSkew())
+
+	// The stale Date of cached responses is ignored.
+	offset = -3 * time.Hour
+	for _, h := range []http.Header{{"X-From-Cache": {"1"}}, {"Age": {"10800"}}} {
+		cached = h
+		_, err = u.GetBodyMeasures(nil)
+		require.NoError(t, err)
+		require.Zero(t, u.Client.ClockSkew())
+	}
+}
@@ -0,0 +1,102 @@
+package withings
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/asymmetricia/withings/enum/meastype"
+)
+
+// DailyWeightAndSteps is the weight and step count of a single local calendar
+// day.
+type DailyWeightAndSteps struct {
+	// Date is the day, formatted as YYYY-MM-DD.
+	Date string
+	// Weight is the last weight in kg measured that day, or nil if the user
+	// didn't weigh in.
+	Weight *float64
+	// Steps is the number of steps taken that day, or nil if no activity was
+	// recorded.
+	Steps *float64
+}
+
+// WeightAndActivity holds daily weights and steps aligned by date, as returned
+// by GetWeightAndActivity.
+type WeightAndActivity struct {
+	// Days has one entry for every day of the requested range, oldest first,
+	// whether or not any data was recorded that day.
+	Days []DailyWeightAndSteps
+}
+
+// GetWeightAndActivity retrieves the user's real weights and daily activity
+// between the days of start and end, inclusive, and aligns them by day for a
+// combined weight trend view. Weights are assigned to the day on which they
+// were taken in their own timezone, as per GroupByDay, and activity to the day
+// it's reported for, so both series line up on the user's local days. All
+// pages of both endpoints are retrieved.
+func (u *User) GetWeightAndActivity(ctx context.Context, start, end time.Time) (*WeightAndActivity, error) {
+	ctx = WithRequestOptions(ctx, noDataAsError(false))
+	r := DateRange{
+		Start: time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location()),
+		End:   time.Date(end.Year(), end.Month(), end.Day()+1, 0, 0, 0, 0, end.Location()).Add(-time.Nanosecond),
+	}
+
+	// Body measures are selected by timestamp, so the window is widened by
+	// a day either side to catch weights taken in other timezones; only
+	// those falling on a requested day are kept below.
+	weightParams := RealMeasuresOfType(meastype.Weight)
+	weightStart, weightEnd := r.Start.AddDate(0, 0, -1), r.End.AddDate(0, 0, 1)
+	weightParams.StartDate = &weightStart
+	weightParams.EndDate = &weightEnd
+	weights, err := u.GetAllBodyMeasuresCtx(ctx, weightParams)
+	if err != nil {
+		return nil, fmt.Errorf("retrieving weights: %w", err)
+	}
+
+	steps := map[string]float64{}
+	activityParams := r.ActivityParams()
+	err = followOffsets(nil, func(offset *int) (bool, int, error) {
+		activityParams.Offset = offset
+		page, err := u.GetActivityMeasuresCtx(ctx, activityParams)
+		if err != nil {
+			return false, 0, err
+		}
+		if page.Body.SingleValue && page.Body.Date != nil && page.Body.Steps != nil {
+			steps[*page.Body.Date] = *page.Body.Steps
+		}
+		for _, a := range page.Body.Activities {
+			steps[a.Date] = a.Steps
+		}
+		return page.Body.More, page.Body.Offset, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("retrieving activity: %w", err)
+	}
+
+	byDay := weights.GroupByDay()
+	result := &WeightAndActivity{}
+	last := r.End.Format("2006-01-02")
+	for day := r.Start; ; day = day.AddDate(0, 0, 1) {
+		d := DailyWeightAndSteps{Date: day.Format("2006-01-02")}
+
+		var latest int64
+		for _, g := range byDay[d.Date] {
+			if w, ok := g.Measure(meastype.Weight); ok && (d.Weight == nil || g.Date >= latest) {
+				w := w
+				d.Weight = &w
+				latest = g.Date
+			}
+		}
+		if s, ok := steps[d.Date]; ok {
+			d.Steps = &s
+		}
+
+		result.Days = append(result.Days, d)
+		if d.Date >= last {
+			break
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,58 @@
+package withings
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetWeightAndActivity(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		q := req.URL.Query()
+		switch q.Get("action") {
+		case "getmeas":
+			require.Equal(t, "1", q.Get("meastype"))
+			require.Equal(t, "1", q.Get("category"))
+			// Nov 7 23:30 and Nov 8 07:00 and 08:00 in Tokyo.
+			fmt.Fprint(rw, `{"status":0,"body":{"timezone":"Asia/Tokyo","measuregrps":[
+				{"grpid":1,"date":1636295400,"category":1,"measures":[{"value":72500,"type":1,"unit":-3}]},
+				{"grpid":2,"date":1636322400,"category":1,"measures":[{"value":72300,"type":1,"unit":-3}]},
+				{"grpid":3,"date":1636326000,"category":1,"measures":[{"value":72100,"type":1,"unit":-3}]}
+			]}}`)
+		case "getactivity":
+			require.Equal(t, "2021-11-07", q.Get("startdateymd"))
+			require.Equal(t, "2021-11-09", q.Get("enddateymd"))
+			fmt.Fprint(rw, `{"status":0,"body":{"activity":[
+				{"date":"2021-11-07","steps":8000,"timezone":"Asia/Tokyo"},
+				{"date":"2021-11-09","steps":4000,"timezone":"Asia/Tokyo"}
+			]}}`)
+		default:
+			t.Errorf("unexpected action %q", q.Get("action"))
+		}
+	})
+
+	start := time.Date(2021, 11, 7, 12, 0, 0, 0, tokyo)
+	end := time.Date(2021, 11, 9, 12, 0, 0, 0, tokyo)
+	wa, err := u.GetWeightAndActivity(context.Background(), start, end)
+	require.NoError(t, err)
+	require.Len(t, wa.Days, 3)
+
+	require.Equal(t, "2021-11-07", wa.Days[0].Date)
+	require.InDelta(t, 72.5, *wa.Days[0].Weight, 0.0001)
+	require.InDelta(t, 8000, *wa.Days[0].Steps, 0.0001)
+
+	require.Equal(t, "2021-11-08", wa.Days[1].Date)
+	require.InDelta(t, 72.1, *wa.Days[1].Weight, 0.0001)
+	require.Nil(t, wa.Days[1].Steps)
+
+	require.Equal(t, "2021-11-09", wa.Days[2].Date)
+	require.Nil(t, wa.Days[2].Weight)
+	require.InDelta(t, 4000, *wa.Days[2].Steps, 0.0001)
+}
@@ -0,0 +1,104 @@
+package withings
+
+import "time"
+
+// DateRange is a span of whole local calendar days, for use in query params.
+// Start is midnight at the beginning of the first day and End is the last
+// instant of the last day, so the range formats correctly both as the
+// YYYY-MM-DD dates of activity, workout and sleep summary queries and as the
+// UNIX times of body measure, intraday and sleep queries.
+type DateRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+// LastNDays returns the range covering today and the n-1 days before it in the
+// local timezone, so LastNDays(7) is the last week including today. Days are
+// counted on the calendar, so the range stays correct across DST changes.
+func LastNDays(n int) DateRange {
+	return lastNDays(n, time.Now())
+}
+
+// Today returns the range covering today in the local timezone.
+func Today() DateRange {
+	return LastNDays(1)
+}
+
+// LastNDaysIn is as per LastNDays, but counts days in loc, such as the user's
+// timezone, rather than the local timezone. If loc is nil, the local timezone
+// is used.
+func LastNDaysIn(n int, loc *time.Location) DateRange {
+	if loc == nil {
+		loc = time.Local
+	}
+	return lastNDays(n, time.Now().In(loc))
+}
+
+// TodayIn returns the range covering today in loc.
+func TodayIn(loc *time.Location) DateRange {
+	return LastNDaysIn(1, loc)
+}
+
+// DaysIn returns the range covering the calendar days of start through end in
+// loc, from local midnight at the beginning of start's day to the end of
+// end's day. Only the year, month and day of start and end are used, so e.g.
+// dates built in UTC still select the intended local days. If loc is nil, the
+// local timezone is used.
+func DaysIn(start, end time.Time, loc *time.Location) DateRange {
+	if loc == nil {
+		loc = time.Local
+	}
+	sy, sm, sd := start.Date()
+	ey, em, ed := end.Date()
+	return DateRange{
+		Start: time.Date(sy, sm, sd, 0, 0, 0, 0, loc),
+		End:   time.Date(ey, em, ed+1, 0, 0, 0, 0, loc).Add(-time.Nanosecond),
+	}
+}
+
+// lastNDays is LastNDays as of now, in the location of now.
+func lastNDays(n int, now time.Time) DateRange {
+	if n < 1 {
+		n = 1
+	}
+	y, m, d := now.Date()
+	start := time.Date(y, m, d-n+1, 0, 0, 0, 0, now.Location())
+	end := time.Date(y, m, d+1, 0, 0, 0, 0, now.Location()).Add(-time.Nanosecond)
+	return DateRange{Start: start, End: end}
+}
+
+// ActivityParams returns activity query params covering the range.
+func (r DateRange) ActivityParams() *ActivityMeasuresQueryParam {
+	start, end := r.Start, r.End
+	return &ActivityMeasuresQueryParam{StartDateYMD: &start, EndDateYMD: &end}
+}
+
+// WorkoutsParams returns workout query params covering the range.
+func (r DateRange) WorkoutsParams() *WorkoutsQueryParam {
+	start, end := r.Start, r.End
+	return &WorkoutsQueryParam{StartDateYMD: &start, EndDateYMD: &end}
+}
+
+// SleepSummaryParams returns sleep summary query params covering the range.
+func (r DateRange) SleepSummaryParams() *SleepSummaryQueryParam {
+	start, end := r.Start, r.End
+	return &SleepSummaryQueryParam{StartDateYMD: &start, EndDateYMD: &end}
+}
+
+// SleepMeasuresParams returns sleep query params covering the range.
+func (r DateRange) SleepMeasuresParams() *SleepMeasuresQueryParam {
+	return &SleepMeasuresQueryParam{StartDate: r.Start, EndDate: r.End}
+}
+
+// BodyMeasuresParams returns body measure query params covering the range.
+func (r DateRange) BodyMeasuresParams() *BodyMeasuresQueryParams {
+	start, end := r.Start, r.End
+	return &BodyMeasuresQueryParams{StartDate: &start, EndDate: &end}
+}
+
+// IntradayActivityParams returns intraday activity query params covering the
+// range.
+func (r DateRange) IntradayActivityParams() *IntradayActivityQueryParam {
+	start, end := r.Start, r.End
+	return &IntradayActivityQueryParam{StartDate: &start, EndDate: &end}
+}
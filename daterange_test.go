@@ -0,0 +1,46 @@
+package withings
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLastNDays(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	// Late evening, spanning the switch to daylight saving time on March 14.
+	now := time.Date(2021, 3, 15, 23, 30, 0, 0, ny)
+	r := lastNDays(7, now)
+	require.Equal(t, time.Date(2021, 3, 9, 0, 0, 0, 0, ny), r.Start)
+	require.Equal(t, "2021-03-09", r.Start.Format("2006-01-02"))
+	require.Equal(t, "2021-03-15", r.End.Format("2006-01-02"))
+	require.Equal(t, time.Date(2021, 3, 16, 0, 0, 0, 0, ny).Unix()-1, r.End.Unix())
+
+	p := r.ActivityParams()
+	require.Equal(t, r.Start, *p.StartDateYMD)
+	require.Equal(t, r.End, *p.EndDateYMD)
+
+	today := lastNDays(1, now)
+	require.Equal(t, "2021-03-15", today.Start.Format("2006-01-02"))
+	require.Equal(t, "2021-03-15", today.End.Format("2006-01-02"))
+}
+
+func TestDaysIn(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+
+	day := time.Date(2021, 3, 15, 0, 0, 0, 0, time.UTC)
+	p := DaysIn(day, day, tokyo).BodyMeasuresParams()
+	require.Equal(t, time.Date(2021, 3, 14, 15, 0, 0, 0, time.UTC).Unix(), p.StartDate.Unix())
+	require.Equal(t, time.Date(2021, 3, 15, 15, 0, 0, 0, time.UTC).Unix()-1, p.EndDate.Unix())
+
+	r := DaysIn(day, day.AddDate(0, 0, 2), tokyo)
+	require.Equal(t, "2021-03-17", r.End.Format("2006-01-02"))
+
+	today := TodayIn(tokyo)
+	require.Equal(t, tokyo, today.Start.Location())
+	require.Equal(t, time.Now().In(tokyo).Format("2006-01-02"), today.Start.Format("2006-01-02"))
+}
@@ -0,0 +1,71 @@
+package withings
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"golang.org/x/oauth2"
+)
+
+// DeauthorizeError is returned by Deauthorize when one or more of its steps
+// failed. Errs holds the error of each failed step; the steps that succeeded
+// are not repeated by calling Deauthorize again. With Go 1.20 or later,
+// errors.Is and errors.As look through it into Errs, e.g. to find the
+// *APIError of a failed revocation.
+type DeauthorizeError struct {
+	Errs []error
+}
+
+func (e *DeauthorizeError) Error() string {
+	msgs := make([]string, len(e.Errs))
+	for i, err := range e.Errs {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("deauthorizing user: %s", strings.Join(msgs, "; "))
+}
+
+func (e *DeauthorizeError) Unwrap() []error {
+	return e.Errs
+}
+
+// Deauthorize disconnects the user, for example when their account is being
+// deleted. It revokes every notification subscription the user has, then
+// discards the user's token so it can no longer be used by this client.
+//
+// Withings does not offer a revocation endpoint for the tokens this client
+// holds, so the token itself stays valid until it expires; the user can remove
+// the application's access from their Withings account settings. Failures to
+// list or revoke subscriptions are collected into a *DeauthorizeError, and the
+// token is discarded only if every subscription was revoked, so Deauthorize can
+// be retried.
+func (u *User) Deauthorize(ctx context.Context) error {
+	var errs []error
+	ctx = WithRequestOptions(ctx, noDataAsError(false))
+
+	list, err := u.ListNotificationsCtx(ctx, nil)
+	if err != nil {
+		return &DeauthorizeError{Errs: []error{fmt.Errorf("listing notifications: %w", err)}}
+	}
+
+	for _, profile := range list.Body.Profiles {
+		cb, err := url.Parse(profile.CallbackURL)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("parsing callback url %q: %w", profile.CallbackURL, err))
+			continue
+		}
+
+		appli := profile.Appli
+		if _, err := u.RevokeNotificationCtx(ctx, &RevokeNotificationParam{CallbackURL: *cb, Appli: &appli}); err != nil {
+			errs = append(errs, fmt.Errorf("revoking notification %q for appli %d: %w", profile.CallbackURL, profile.Appli, err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return &DeauthorizeError{Errs: errs}
+	}
+
+	u.OauthToken = &oauth2.Token{}
+	return nil
+}
@@ -0,0 +1,99 @@
+package withings
+
+import "time"
+
+// seconds converts a number of seconds, as sent by the API, to a duration.
+func seconds(s int64) time.Duration {
+	return time.Duration(s) * time.Second
+}
+
+// optionalSeconds is as per seconds, but for durations the API may omit, which
+// are then zero.
+func optionalSeconds(s *int) time.Duration {
+	if s == nil {
+		return 0
+	}
+	return seconds(int64(*s))
+}
+
+// Duration returns the time from the start to the end of the night.
+func (s SleepSummary) Duration() time.Duration {
+	return seconds(s.EndDate - s.StartDate)
+}
+
+// TimeToSleep returns how long the user took to fall asleep.
+func (s SleepSummary) TimeToSleep() time.Duration {
+	return seconds(int64(s.Data.DurationToSleep))
+}
+
+// TimeToWakeUp returns how long the user took to get up after waking, or zero
+// if it wasn't reported.
+func (s SleepSummary) TimeToWakeUp() time.Duration {
+	return optionalSeconds(s.Data.DurationToWakeUp)
+}
+
+// AwakeDuration returns the time the user spent awake during the night.
+func (s SleepSummary) AwakeDuration() time.Duration {
+	return seconds(int64(s.Data.WakeUpDuration))
+}
+
+// LightSleepDuration returns the time the user spent in light sleep.
+func (s SleepSummary) LightSleepDuration() time.Duration {
+	return seconds(int64(s.Data.LightSleepDuration))
+}
+
+// DeepSleepDuration returns the time the user spent in deep sleep.
+func (s SleepSummary) DeepSleepDuration() time.Duration {
+	return seconds(int64(s.Data.DeepSleepDuration))
+}
+
+// REMSleepDuration returns the time the user spent in REM sleep, or zero if
+// the device doesn't report it.
+func (s SleepSummary) REMSleepDuration() time.Duration {
+	return optionalSeconds(s.Data.REMSleepDuration)
+}
+
+// SnoringDuration returns the time the user spent snoring, or zero if it
+// wasn't reported. It is only reported if requested with
+// sleepsummaryfield.Snoring.
+func (s SleepSummary) SnoringDuration() time.Duration {
+	return optionalSeconds(s.Data.Snoring)
+}
+
+// Duration returns the length of the sleep segment.
+func (m SleepMeasure) Duration() time.Duration {
+	return seconds(m.EndDate - m.StartDate)
+}
+
+// Duration returns the time from the start to the end of the workout,
+// including any pauses.
+func (w Workout) Duration() time.Duration {
+	return seconds(w.EndDate - w.StartDate)
+}
+
+// PauseDuration returns the time the workout was paused for, or zero if it
+// wasn't reported.
+func (w Workout) PauseDuration() time.Duration {
+	return seconds(int64(w.Data["pause_duration"]))
+}
+
+// SoftDuration returns the time spent in soft activity during the day.
+func (a Activity) SoftDuration() time.Duration {
+	return seconds(int64(a.Soft))
+}
+
+// ModerateDuration returns the time spent in moderate activity during the day.
+func (a Activity) ModerateDuration() time.Duration {
+	return seconds(int64(a.Moderate))
+}
+
+// IntenseDuration returns the time spent in intense activity during the day.
+func (a Activity) IntenseDuration() time.Duration {
+	return seconds(int64(a.Intense))
+}
+
+// ActiveDuration returns the time the sample's activity lasted, or zero if it
+// wasn't reported.
+func (a IntraDayActivity) ActiveDuration() time.Duration {
+	return optionalSeconds(a.Duration)
+}
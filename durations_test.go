@@ -0,0 +1,41 @@
+package withings
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDurations(t *testing.T) {
+	rem := 3600
+	s := SleepSummary{
+		StartDate: 1609470000,
+		EndDate:   1609498800,
+		Data: SleepSummaryData{
+			WakeUpDuration:     600,
+			LightSleepDuration: 14400,
+			DeepSleepDuration:  7200,
+			REMSleepDuration:   &rem,
+			DurationToSleep:    900,
+		},
+	}
+	require.Equal(t, 8*time.Hour, s.Duration())
+	require.Equal(t, 15*time.Minute, s.TimeToSleep())
+	require.Equal(t, time.Duration(0), s.TimeToWakeUp())
+	require.Equal(t, 10*time.Minute, s.AwakeDuration())
+	require.Equal(t, 4*time.Hour, s.LightSleepDuration())
+	require.Equal(t, 2*time.Hour, s.DeepSleepDuration())
+	require.Equal(t, time.Hour, s.REMSleepDuration())
+
+	w := Workout{StartDate: 1609524000, EndDate: 1609527600, Data: map[string]float64{"pause_duration": 120}}
+	require.Equal(t, time.Hour, w.Duration())
+	require.Equal(t, 2*time.Minute, w.PauseDuration())
+
+	a := Activity{Soft: 1800, Moderate: 900, Intense: 60}
+	require.Equal(t, 30*time.Minute, a.SoftDuration())
+	require.Equal(t, 15*time.Minute, a.ModerateDuration())
+	require.Equal(t, time.Minute, a.IntenseDuration())
+
+	require.Equal(t, time.Duration(0), IntraDayActivity{}.ActiveDuration())
+}
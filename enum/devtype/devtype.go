@@ -6,8 +6,18 @@ type DevType int
 // DevType constants for the Withings api.
 const (
 	UserRelated          DevType = 0
-	BodyScale                    = 1
-	BloodPressureMonitor         = 4
-	ActivityTracker              = 16
-	SleepMonitor                 = 32
+	BodyScale            DevType = 1
+	BloodPressureMonitor DevType = 4
+	ActivityTracker      DevType = 16
+	SleepMonitor         DevType = 32
 )
+
+// Known reports whether the value is one of the constants above. The API may
+// return values added after this package was last updated.
+func (i DevType) Known() bool {
+	switch i {
+	case UserRelated, BodyScale, BloodPressureMonitor, ActivityTracker, SleepMonitor:
+		return true
+	}
+	return false
+}
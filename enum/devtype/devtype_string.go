@@ -4,13 +4,39 @@ package devtype
 
 import "strconv"
 
-const _DevType_name = "UserRelated"
+func _() {
+	// An "invalid array index" compiler error signifies that the constant values have changed.
+	// Re-run the stringer command to generate them again.
+	var x [1]struct{}
+	_ = x[UserRelated-0]
+	_ = x[BodyScale-1]
+	_ = x[BloodPressureMonitor-4]
+	_ = x[ActivityTracker-16]
+	_ = x[SleepMonitor-32]
+}
+
+const (
+	_DevType_name_0 = "UserRelatedBodyScale"
+	_DevType_name_1 = "BloodPressureMonitor"
+	_DevType_name_2 = "ActivityTracker"
+	_DevType_name_3 = "SleepMonitor"
+)
 
-var _DevType_index = [...]uint8{0, 11}
+var (
+	_DevType_index_0 = [...]uint8{0, 11, 20}
+)
 
 func (i DevType) String() string {
-	if i < 0 || i >= DevType(len(_DevType_index)-1) {
+	switch {
+	case 0 <= i && i <= 1:
+		return _DevType_name_0[_DevType_index_0[i]:_DevType_index_0[i+1]]
+	case i == 4:
+		return _DevType_name_1
+	case i == 16:
+		return _DevType_name_2
+	case i == 32:
+		return _DevType_name_3
+	default:
 		return "DevType(" + strconv.FormatInt(int64(i), 10) + ")"
 	}
-	return _DevType_name[_DevType_index[i]:_DevType_index[i+1]]
 }
@@ -0,0 +1,26 @@
+package intradayfield
+
+// Field is a metric of intraday activity, as named by the data_fields
+// parameter of the API.
+type Field string
+
+const (
+	Steps     Field = "steps"
+	Elevation Field = "elevation"
+	Calories  Field = "calories"
+	Distance  Field = "distance"
+	Stroke    Field = "stroke"
+	PoolLap   Field = "pool_lap"
+	Duration  Field = "duration"
+	HeartRate Field = "heart_rate"
+	SpO2Auto  Field = "spo2_auto"
+)
+
+// Known reports whether the value is one of the constants above.
+func (f Field) Known() bool {
+	switch f {
+	case Steps, Elevation, Calories, Distance, Stroke, PoolLap, Duration, HeartRate, SpO2Auto:
+		return true
+	}
+	return false
+}
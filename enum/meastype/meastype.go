@@ -6,19 +6,32 @@ type MeasType int
 // MeasType constants for the Withings api.
 const (
 	Weight                     MeasType = 1
-	Height                              = 4
-	FatFreeMassKg                       = 5
-	FatRatio                            = 6
-	FatMassWeightKg                     = 8
-	DiastolicBloodPressureMMHG          = 9
-	SystolicBloodPressureMMHG           = 10
-	HeartPulseBPM                       = 11
-	Temperature                         = 12
-	SP02Percent                         = 54
-	BodyTemperature                     = 71
-	SkinTemperature                     = 73
-	MuscleMass                          = 76
-	Hydration                           = 77
-	BoneMass                            = 88
-	PulseWaveVelocity                   = 91
+	Height                     MeasType = 4
+	FatFreeMassKg              MeasType = 5
+	FatRatio                   MeasType = 6
+	FatMassWeightKg            MeasType = 8
+	DiastolicBloodPressureMMHG MeasType = 9
+	SystolicBloodPressureMMHG  MeasType = 10
+	HeartPulseBPM              MeasType = 11
+	Temperature                MeasType = 12
+	SP02Percent                MeasType = 54
+	BodyTemperature            MeasType = 71
+	SkinTemperature            MeasType = 73
+	MuscleMass                 MeasType = 76
+	Hydration                  MeasType = 77
+	BoneMass                   MeasType = 88
+	PulseWaveVelocity          MeasType = 91
 )
+
+// Known reports whether the value is one of the constants above. The API may
+// return values added after this package was last updated.
+func (i MeasType) Known() bool {
+	switch i {
+	case Weight, Height, FatFreeMassKg, FatRatio, FatMassWeightKg,
+		DiastolicBloodPressureMMHG, SystolicBloodPressureMMHG, HeartPulseBPM,
+		Temperature, SP02Percent, BodyTemperature, SkinTemperature, MuscleMass,
+		Hydration, BoneMass, PulseWaveVelocity:
+		return true
+	}
+	return false
+}
@@ -4,14 +4,70 @@ package meastype
 
 import "strconv"
 
-const _MeasType_name = "Weight"
+func _() {
+	// An "invalid array index" compiler error signifies that the constant values have changed.
+	// Re-run the stringer command to generate them again.
+	var x [1]struct{}
+	_ = x[Weight-1]
+	_ = x[Height-4]
+	_ = x[FatFreeMassKg-5]
+	_ = x[FatRatio-6]
+	_ = x[FatMassWeightKg-8]
+	_ = x[DiastolicBloodPressureMMHG-9]
+	_ = x[SystolicBloodPressureMMHG-10]
+	_ = x[HeartPulseBPM-11]
+	_ = x[Temperature-12]
+	_ = x[SP02Percent-54]
+	_ = x[BodyTemperature-71]
+	_ = x[SkinTemperature-73]
+	_ = x[MuscleMass-76]
+	_ = x[Hydration-77]
+	_ = x[BoneMass-88]
+	_ = x[PulseWaveVelocity-91]
+}
+
+const (
+	_MeasType_name_0 = "Weight"
+	_MeasType_name_1 = "HeightFatFreeMassKgFatRatio"
+	_MeasType_name_2 = "FatMassWeightKgDiastolicBloodPressureMMHGSystolicBloodPressureMMHGHeartPulseBPMTemperature"
+	_MeasType_name_3 = "SP02Percent"
+	_MeasType_name_4 = "BodyTemperature"
+	_MeasType_name_5 = "SkinTemperature"
+	_MeasType_name_6 = "MuscleMassHydration"
+	_MeasType_name_7 = "BoneMass"
+	_MeasType_name_8 = "PulseWaveVelocity"
+)
 
-var _MeasType_index = [...]uint8{0, 6}
+var (
+	_MeasType_index_1 = [...]uint8{0, 6, 19, 27}
+	_MeasType_index_2 = [...]uint8{0, 15, 41, 66, 79, 90}
+	_MeasType_index_6 = [...]uint8{0, 10, 19}
+)
 
 func (i MeasType) String() string {
-	i -= 1
-	if i < 0 || i >= MeasType(len(_MeasType_index)-1) {
-		return "MeasType(" + strconv.FormatInt(int64(i+1), 10) + ")"
+	switch {
+	case i == 1:
+		return _MeasType_name_0
+	case 4 <= i && i <= 6:
+		i -= 4
+		return _MeasType_name_1[_MeasType_index_1[i]:_MeasType_index_1[i+1]]
+	case 8 <= i && i <= 12:
+		i -= 8
+		return _MeasType_name_2[_MeasType_index_2[i]:_MeasType_index_2[i+1]]
+	case i == 54:
+		return _MeasType_name_3
+	case i == 71:
+		return _MeasType_name_4
+	case i == 73:
+		return _MeasType_name_5
+	case 76 <= i && i <= 77:
+		i -= 76
+		return _MeasType_name_6[_MeasType_index_6[i]:_MeasType_index_6[i+1]]
+	case i == 88:
+		return _MeasType_name_7
+	case i == 91:
+		return _MeasType_name_8
+	default:
+		return "MeasType(" + strconv.FormatInt(int64(i), 10) + ")"
 	}
-	return _MeasType_name[_MeasType_index[i]:_MeasType_index[i+1]]
 }
@@ -0,0 +1,31 @@
+package meastype
+
+// CanonicalUnit describes how to interpret the value/unit pair returned for a
+// measure of type t. The real value of a measure is value * 10^unit, and that
+// result is expressed in the unit called name.
+//
+// siExponent is the power of ten a value in that unit must be multiplied by to
+// express it in the corresponding SI unit. It is zero when the unit already is
+// the SI unit, or when it has no decimal relationship to one (mmHg, bpm). For
+// example a fat ratio of 23.5 "%" is 0.235 when multiplied by 10^-2.
+//
+// An empty name is returned for types that are not known.
+func CanonicalUnit(t MeasType) (name string, siExponent int) {
+	switch t {
+	case Weight, FatFreeMassKg, FatMassWeightKg, MuscleMass, Hydration, BoneMass:
+		return "kg", 0
+	case Height:
+		return "m", 0
+	case FatRatio, SP02Percent:
+		return "%", -2
+	case DiastolicBloodPressureMMHG, SystolicBloodPressureMMHG:
+		return "mmHg", 0
+	case HeartPulseBPM:
+		return "bpm", 0
+	case Temperature, BodyTemperature, SkinTemperature:
+		return "°C", 0
+	case PulseWaveVelocity:
+		return "m/s", 0
+	}
+	return "", 0
+}
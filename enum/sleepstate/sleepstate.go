@@ -5,7 +5,17 @@ type SleepState int
 
 const (
 	Awake      SleepState = 0
-	LightSleep            = 1
-	DeepSleep             = 2
-	REM                   = 3
+	LightSleep SleepState = 1
+	DeepSleep  SleepState = 2
+	REM        SleepState = 3
 )
+
+// Known reports whether the value is one of the constants above. The API may
+// return values added after this package was last updated.
+func (i SleepState) Known() bool {
+	switch i {
+	case Awake, LightSleep, DeepSleep, REM:
+		return true
+	}
+	return false
+}
@@ -4,13 +4,24 @@ package sleepstate
 
 import "strconv"
 
-const _SleepState_name = "Awake"
+func _() {
+	// An "invalid array index" compiler error signifies that the constant values have changed.
+	// Re-run the stringer command to generate them again.
+	var x [1]struct{}
+	_ = x[Awake-0]
+	_ = x[LightSleep-1]
+	_ = x[DeepSleep-2]
+	_ = x[REM-3]
+}
+
+const _SleepState_name = "AwakeLightSleepDeepSleepREM"
 
-var _SleepState_index = [...]uint8{0, 5}
+var _SleepState_index = [...]uint8{0, 5, 15, 24, 27}
 
 func (i SleepState) String() string {
-	if i < 0 || i >= SleepState(len(_SleepState_index)-1) {
+	idx := int(i) - 0
+	if i < 0 || idx >= len(_SleepState_index)-1 {
 		return "SleepState(" + strconv.FormatInt(int64(i), 10) + ")"
 	}
-	return _SleepState_name[_SleepState_index[i]:_SleepState_index[i+1]]
+	return _SleepState_name[_SleepState_index[idx]:_SleepState_index[idx+1]]
 }
@@ -0,0 +1,39 @@
+package sleepsummaryfield
+
+// Field is a metric of a sleep summary, as named by the data_fields parameter
+// of the API and the keys of the summary's data.
+type Field string
+
+const (
+	BreathingDisturbancesIntensity Field = "breathing_disturbances_intensity"
+	ApneaHypopneaIndex             Field = "apnea_hypopnea_index"
+	DeepSleepDuration              Field = "deepsleepduration"
+	DurationToSleep                Field = "durationtosleep"
+	DurationToWakeUp               Field = "durationtowakeup"
+	HRAverage                      Field = "hr_average"
+	HRMax                          Field = "hr_max"
+	HRMin                          Field = "hr_min"
+	LightSleepDuration             Field = "lightsleepduration"
+	REMSleepDuration               Field = "remsleepduration"
+	RRAverage                      Field = "rr_average"
+	RRMax                          Field = "rr_max"
+	RRMin                          Field = "rr_min"
+	SleepScore                     Field = "sleep_score"
+	Snoring                        Field = "snoring"
+	SnoringEpisodeCount            Field = "snoringepisodecount"
+	WakeUpCount                    Field = "wakeupcount"
+	WakeUpDuration                 Field = "wakeupduration"
+)
+
+// Known reports whether the value is one of the constants above.
+func (f Field) Known() bool {
+	switch f {
+	case BreathingDisturbancesIntensity, ApneaHypopneaIndex,
+		DeepSleepDuration, DurationToSleep, DurationToWakeUp,
+		HRAverage, HRMax, HRMin, LightSleepDuration, REMSleepDuration,
+		RRAverage, RRMax, RRMin, SleepScore, Snoring, SnoringEpisodeCount,
+		WakeUpCount, WakeUpDuration:
+		return true
+	}
+	return false
+}
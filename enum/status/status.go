@@ -16,6 +16,7 @@ const (
 	UserIsDeactiviated                         Status = 328
 	SignatureIsInvalid                         Status = 342
 	WrongNotificationCallbackURL               Status = 343
+	InvalidParams                              Status = 503
 	TooManyRequets                             Status = 601
 	WrongActionOrWrongWebservice               Status = 2554
 	UnknonwError                               Status = 2555
@@ -4,7 +4,30 @@ package status
 
 import "strconv"
 
-const _Status_name = "OperationWasSuccessfulTheUserIDProvidedIsAbsentOrIncorrectTheProvidedUserIDAndOrOauthCredsDoNotMatchTokenIsInvalidOrDoesntExistNoSuchSubscriptionNoSuchSubscriptionCouldBeDeletedCommentAbsentOrIncorrectTooManyNotificationsSetUserIsDeactiviatedSignatureIsInvalidWrongNotificationCallbackURLTooManyRequetsWrongActionOrWrongWebserviceUnknonwErrorServiceNotDefined"
+func _() {
+	// An "invalid array index" compiler error signifies that the constant values have changed.
+	// Re-run the stringer command to generate them again.
+	var x [1]struct{}
+	_ = x[OperationWasSuccessful-0]
+	_ = x[TheUserIDProvidedIsAbsentOrIncorrect-247]
+	_ = x[TheProvidedUserIDAndOrOauthCredsDoNotMatch-250]
+	_ = x[TokenIsInvalidOrDoesntExist-283]
+	_ = x[NoSuchSubscription-286]
+	_ = x[TheCallbackURLIsEitherAbsentOrIncorrect-283]
+	_ = x[NoSuchSubscriptionCouldBeDeleted-294]
+	_ = x[CommentAbsentOrIncorrect-304]
+	_ = x[TooManyNotificationsSet-305]
+	_ = x[UserIsDeactiviated-328]
+	_ = x[SignatureIsInvalid-342]
+	_ = x[WrongNotificationCallbackURL-343]
+	_ = x[InvalidParams-503]
+	_ = x[TooManyRequets-601]
+	_ = x[WrongActionOrWrongWebservice-2554]
+	_ = x[UnknonwError-2555]
+	_ = x[ServiceNotDefined-2556]
+}
+
+const _Status_name = "OperationWasSuccessfulTheUserIDProvidedIsAbsentOrIncorrectTheProvidedUserIDAndOrOauthCredsDoNotMatchTokenIsInvalidOrDoesntExistNoSuchSubscriptionNoSuchSubscriptionCouldBeDeletedCommentAbsentOrIncorrectTooManyNotificationsSetUserIsDeactiviatedSignatureIsInvalidWrongNotificationCallbackURLInvalidParamsTooManyRequetsWrongActionOrWrongWebserviceUnknonwErrorServiceNotDefined"
 
 var _Status_map = map[Status]string{
 	0:    _Status_name[0:22],
@@ -18,10 +41,11 @@ var _Status_map = map[Status]string{
 	328:  _Status_name[224:242],
 	342:  _Status_name[242:260],
 	343:  _Status_name[260:288],
-	601:  _Status_name[288:302],
-	2554: _Status_name[302:330],
-	2555: _Status_name[330:342],
-	2556: _Status_name[342:359],
+	503:  _Status_name[288:301],
+	601:  _Status_name[301:315],
+	2554: _Status_name[315:343],
+	2555: _Status_name[343:355],
+	2556: _Status_name[355:372],
 }
 
 func (i Status) String() string {
@@ -0,0 +1,40 @@
+package workoutfield
+
+// Field is a metric of a workout, as named by the data_fields parameter of the
+// API and used as the key of Workout.Data.
+type Field string
+
+const (
+	Calories          Field = "calories"
+	Intensity         Field = "intensity"
+	ManualDistance    Field = "manual_distance"
+	ManualCalories    Field = "manual_calories"
+	HRAverage         Field = "hr_average"
+	HRMin             Field = "hr_min"
+	HRMax             Field = "hr_max"
+	HRZone0           Field = "hr_zone_0"
+	HRZone1           Field = "hr_zone_1"
+	HRZone2           Field = "hr_zone_2"
+	HRZone3           Field = "hr_zone_3"
+	PauseDuration     Field = "pause_duration"
+	AlgoPauseDuration Field = "algo_pause_duration"
+	SpO2Average       Field = "spo2_average"
+	Steps             Field = "steps"
+	Distance          Field = "distance"
+	Elevation         Field = "elevation"
+	PoolLaps          Field = "pool_laps"
+	Strokes           Field = "strokes"
+	PoolLength        Field = "pool_length"
+)
+
+// Known reports whether the value is one of the constants above.
+func (f Field) Known() bool {
+	switch f {
+	case Calories, Intensity, ManualDistance, ManualCalories,
+		HRAverage, HRMin, HRMax, HRZone0, HRZone1, HRZone2, HRZone3,
+		PauseDuration, AlgoPauseDuration, SpO2Average,
+		Steps, Distance, Elevation, PoolLaps, Strokes, PoolLength:
+		return true
+	}
+	return false
+}
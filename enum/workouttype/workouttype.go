@@ -49,3 +49,18 @@ const (
 	Climbing     WorkoutType = 195
 	IceSkating   WorkoutType = 196
 )
+
+// Known reports whether the value is one of the constants above. The API may
+// return values added after this package was last updated.
+func (i WorkoutType) Known() bool {
+	switch i {
+	case Walk, Run, Hiking, Staking, BMX, Bicycling, Swim, Surfing,
+		KiteSurfing, WindSurfing, Bodyboard, Tennis, TableTennis, Squash,
+		Badminton, LiftWeights, Calisthenics, Elliptical, Pilate, Basketball,
+		Soccer, Football, Rugby, Vollyball, WaterPolo, HorseRiding, Golf, Yoga,
+		Dancing, Boxing, Fencing, Wrestling, MartialArts, Skiing, SnowBoarding,
+		Base, Rowing, Zumba, Baseball, Handball, Hockey, Climbing, IceSkating:
+		return true
+	}
+	return false
+}
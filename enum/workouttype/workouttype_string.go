@@ -0,0 +1,87 @@
+// Code generated by "stringer -type=WorkoutType"; DO NOT EDIT.
+
+package workouttype
+
+import "strconv"
+
+func _() {
+	// An "invalid array index" compiler error signifies that the constant values have changed.
+	// Re-run the stringer command to generate them again.
+	var x [1]struct{}
+	_ = x[Walk-1]
+	_ = x[Run-2]
+	_ = x[Hiking-3]
+	_ = x[Staking-4]
+	_ = x[BMX-5]
+	_ = x[Bicycling-6]
+	_ = x[Swim-7]
+	_ = x[Surfing-8]
+	_ = x[KiteSurfing-9]
+	_ = x[WindSurfing-10]
+	_ = x[Bodyboard-11]
+	_ = x[Tennis-12]
+	_ = x[TableTennis-13]
+	_ = x[Squash-14]
+	_ = x[Badminton-15]
+	_ = x[LiftWeights-16]
+	_ = x[Calisthenics-17]
+	_ = x[Elliptical-18]
+	_ = x[Pilate-19]
+	_ = x[Basketball-20]
+	_ = x[Soccer-21]
+	_ = x[Football-22]
+	_ = x[Rugby-23]
+	_ = x[Vollyball-24]
+	_ = x[WaterPolo-25]
+	_ = x[HorseRiding-26]
+	_ = x[Golf-27]
+	_ = x[Yoga-28]
+	_ = x[Dancing-29]
+	_ = x[Boxing-30]
+	_ = x[Fencing-31]
+	_ = x[Wrestling-32]
+	_ = x[MartialArts-33]
+	_ = x[Skiing-34]
+	_ = x[SnowBoarding-35]
+	_ = x[Base-186]
+	_ = x[Rowing-187]
+	_ = x[Zumba-188]
+	_ = x[Baseball-191]
+	_ = x[Handball-192]
+	_ = x[Hockey-194]
+	_ = x[Climbing-195]
+	_ = x[IceSkating-196]
+}
+
+const (
+	_WorkoutType_name_0 = "WalkRunHikingStakingBMXBicyclingSwimSurfingKiteSurfingWindSurfingBodyboardTennisTableTennisSquashBadmintonLiftWeightsCalisthenicsEllipticalPilateBasketballSoccerFootballRugbyVollyballWaterPoloHorseRidingGolfYogaDancingBoxingFencingWrestlingMartialArtsSkiingSnowBoarding"
+	_WorkoutType_name_1 = "BaseRowingZumba"
+	_WorkoutType_name_2 = "BaseballHandball"
+	_WorkoutType_name_3 = "HockeyClimbingIceSkating"
+)
+
+var (
+	_WorkoutType_index_0 = [...]uint16{0, 4, 7, 13, 20, 23, 32, 36, 43, 54, 65, 74, 80, 91, 97, 106, 117, 129, 139, 145, 155, 161, 169, 174, 183, 192, 203, 207, 211, 218, 224, 231, 240, 251, 257, 269}
+	_WorkoutType_index_1 = [...]uint8{0, 4, 10, 15}
+	_WorkoutType_index_2 = [...]uint8{0, 8, 16}
+	_WorkoutType_index_3 = [...]uint8{0, 6, 14, 24}
+)
+
+func (i WorkoutType) String() string {
+	switch {
+	case 1 <= i && i <= 35:
+		i -= 1
+		return _WorkoutType_name_0[_WorkoutType_index_0[i]:_WorkoutType_index_0[i+1]]
+	case 186 <= i && i <= 188:
+		i -= 186
+		return _WorkoutType_name_1[_WorkoutType_index_1[i]:_WorkoutType_index_1[i+1]]
+	case 191 <= i && i <= 192:
+		i -= 191
+		return _WorkoutType_name_2[_WorkoutType_index_2[i]:_WorkoutType_index_2[i+1]]
+	case 194 <= i && i <= 196:
+		i -= 194
+		return _WorkoutType_name_3[_WorkoutType_index_3[i]:_WorkoutType_index_3[i+1]]
+	default:
+		return "WorkoutType(" + strconv.FormatInt(int64(i), 10) + ")"
+	}
+}
@@ -0,0 +1,61 @@
+package withings
+
+import (
+	"fmt"
+	"strings"
+)
+
+// checkEnums returns an *UnknownEnumError for the first measure of an unknown
+// type.
+func (b *BodyMeasureRespBody) checkEnums() error {
+	for _, g := range b.MeasureGrps {
+		for _, m := range g.Measures {
+			if !m.Type.Known() {
+				return &UnknownEnumError{Enum: "MeasType", Value: int(m.Type)}
+			}
+		}
+	}
+	return nil
+}
+
+// checkEnums returns an *UnknownEnumError for the first workout of an unknown
+// category.
+func (b *WorkoutRespBody) checkEnums() error {
+	for _, w := range b.Series {
+		if w.Category != nil && !w.Category.Known() {
+			return &UnknownEnumError{Enum: "WorkoutType", Value: int(*w.Category)}
+		}
+	}
+	return nil
+}
+
+// checkEnums returns an *UnknownEnumError for the first sleep measure of an
+// unknown state.
+func (b *SleepMeasuresRespBody) checkEnums() error {
+	for _, m := range b.Series {
+		if !m.State.Known() {
+			return &UnknownEnumError{Enum: "SleepState", Value: int(m.State)}
+		}
+	}
+	return nil
+}
+
+// dataField is a metric that can be selected with the data_fields parameter.
+type dataField interface {
+	~string
+	Known() bool
+}
+
+// joinDataFields returns fields as the value of a data_fields parameter, or an
+// error naming the first one that isn't known. kind describes the fields in
+// the error, e.g. "workout".
+func joinDataFields[F dataField](kind string, fields []F) (string, error) {
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		if !f.Known() {
+			return "", fmt.Errorf("unknown %s data field %q", kind, string(f))
+		}
+		names[i] = string(f)
+	}
+	return strings.Join(names, ","), nil
+}
@@ -0,0 +1,80 @@
+package withings
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Keys of the map produced by User.ExportEnv and consumed by Client.ImportEnv.
+// They are suitable for use as environment variable names.
+const (
+	EnvAccessToken  = "WITHINGS_ACCESS_TOKEN"
+	EnvRefreshToken = "WITHINGS_REFRESH_TOKEN"
+	EnvTokenExpiry  = "WITHINGS_TOKEN_EXPIRY"
+	EnvUserID       = "WITHINGS_USER_ID"
+	EnvScope        = "WITHINGS_SCOPE"
+)
+
+// ExportEnv returns the user's token as strings, keyed by the EnvXxx
+// constants, for moving a user between environments. The expiry is formatted
+// as RFC 3339. The user id and scope are only known for tokens issued by this
+// client and are left empty otherwise.
+func (u *User) ExportEnv() map[string]string {
+	env := map[string]string{
+		EnvAccessToken:  "",
+		EnvRefreshToken: "",
+		EnvTokenExpiry:  "",
+		EnvUserID:       "",
+		EnvScope:        "",
+	}
+	if u.OauthToken == nil {
+		return env
+	}
+
+	env[EnvAccessToken] = u.OauthToken.AccessToken
+	env[EnvRefreshToken] = u.OauthToken.RefreshToken
+	if !u.OauthToken.Expiry.IsZero() {
+		env[EnvTokenExpiry] = u.OauthToken.Expiry.Format(time.RFC3339)
+	}
+	env[EnvUserID], _ = u.OauthToken.Extra("userid").(string)
+	env[EnvScope], _ = u.OauthToken.Extra("scope").(string)
+
+	return env
+}
+
+// ImportEnv creates a user from a map produced by ExportEnv. Only the refresh
+// token is required; if the access token is missing or has expired, a new one
+// is retrieved as per NewUserFromAccessToken.
+func (c *Client) ImportEnv(ctx context.Context, env map[string]string) (*User, error) {
+	if env[EnvRefreshToken] == "" {
+		return nil, fmt.Errorf("importing user: %s is required", EnvRefreshToken)
+	}
+
+	var expiry time.Time
+	if s := env[EnvTokenExpiry]; s != "" {
+		var err error
+		expiry, err = time.Parse(time.RFC3339, s)
+		if err != nil {
+			return nil, fmt.Errorf("importing user: parsing %s: %w", EnvTokenExpiry, err)
+		}
+	}
+	if env[EnvAccessToken] == "" {
+		expiry = time.Time{}
+	}
+
+	u, err := c.NewUserFromAccessToken(ctx, env[EnvAccessToken], expiry, env[EnvRefreshToken])
+	if err != nil {
+		return nil, fmt.Errorf("importing user: %w", err)
+	}
+
+	// A refreshed token already carries what Withings reported.
+	if u.OauthToken.Extra("scope") == nil {
+		u.OauthToken = u.OauthToken.WithExtra(map[string]interface{}{
+			"scope":  env[EnvScope],
+			"userid": env[EnvUserID],
+		})
+	}
+
+	return u, nil
+}
@@ -0,0 +1,46 @@
+package withings
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExportImportEnv(t *testing.T) {
+	c := NewClient("client-id", "client-secret", "http://localhost:8888")
+	expiry := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	u := &User{Client: &c}
+	u.OauthToken = tokenResponse{
+		AccessToken:  "access-token",
+		RefreshToken: "refresh-token",
+		Scope:        "user.metrics",
+		UserId:       "1234",
+	}.token(time.Now())
+	u.OauthToken.Expiry = expiry
+
+	env := u.ExportEnv()
+	require.Equal(t, map[string]string{
+		EnvAccessToken:  "access-token",
+		EnvRefreshToken: "refresh-token",
+		EnvTokenExpiry:  expiry.Format(time.RFC3339),
+		EnvUserID:       "1234",
+		EnvScope:        "user.metrics",
+	}, env)
+
+	imported, err := c.ImportEnv(context.Background(), env)
+	require.NoError(t, err)
+	require.Equal(t, "access-token", imported.OauthToken.AccessToken)
+	require.Equal(t, "refresh-token", imported.OauthToken.RefreshToken)
+	require.True(t, expiry.Equal(imported.OauthToken.Expiry))
+	require.Equal(t, []Scope{ScopeUserMetrics}, imported.Scopes())
+	require.Equal(t, env, imported.ExportEnv())
+}
+
+func TestImportEnvRequiresRefreshToken(t *testing.T) {
+	c := NewClient("client-id", "client-secret", "http://localhost:8888")
+	_, err := c.ImportEnv(context.Background(), map[string]string{EnvAccessToken: "access-token"})
+	require.Error(t, err)
+}
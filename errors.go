@@ -0,0 +1,103 @@
+package withings
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/asymmetricia/withings/enum/status"
+)
+
+// NetworkError is returned when a request could not be completed at the HTTP
+// level: the connection failed or timed out, the response could not be read,
+// or the server answered with a non-2XX HTTP status. Such failures are
+// usually transient and the request may be sent again.
+type NetworkError struct {
+	// StatusCode is the HTTP status code of the response, or zero if no
+	// response was received.
+	StatusCode int
+	Err        error
+}
+
+func (e *NetworkError) Error() string {
+	if e.StatusCode != 0 {
+		return fmt.Sprintf("network error: non-2XX %d from server: %v", e.StatusCode, e.Err)
+	}
+	return fmt.Sprintf("network error: %v", e.Err)
+}
+
+func (e *NetworkError) Unwrap() error {
+	return e.Err
+}
+
+// APIError is returned when Withings received and processed a request but
+// answered with a non-zero status in the response body, such as an invalid or
+// expired token or invalid parameters. Sending the same request again will
+// usually fail the same way.
+type APIError struct {
+	Status  status.Status
+	Message string
+	// Body is the full response body.
+	Body []byte
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf(
+		"api returned an error: status %d: %s, see "+
+			"https://developer.withings.com/api-reference/#section/Response-status",
+		int(e.Status),
+		e.Message,
+	)
+}
+
+// ErrNoData is returned, along with the empty response, by requests made with
+// the NoDataAsError option when the call succeeded but the requested window
+// holds no data. Without the option such responses only have NoData set.
+var ErrNoData = errors.New("no data for the requested window")
+
+// ErrNoHeight and ErrNoWeight are returned when computing a BMI without a
+// height or weight measure to compute it from.
+var (
+	ErrNoHeight = errors.New("no height measure")
+	ErrNoWeight = errors.New("no weight measure")
+)
+
+// ErrUnsupportedAppli is returned by FetchAppli for applis whose data can't be
+// retrieved with this package.
+var ErrUnsupportedAppli = errors.New("no endpoint serves this appli")
+
+// UnknownEnumError is returned by clients with StrictEnums set when a response
+// holds an enum value this package doesn't know, such as a measure type added
+// to the API since it was last updated.
+type UnknownEnumError struct {
+	// Enum is the name of the enum, e.g. "MeasType".
+	Enum  string
+	Value int
+}
+
+func (e *UnknownEnumError) Error() string {
+	return fmt.Sprintf("unknown %s value %d", e.Enum, e.Value)
+}
+
+// networkError wraps err in a NetworkError unless it already reports an API
+// level failure, such as Withings rejecting a token refresh made on the way.
+func networkError(err error) error {
+	var apiErr *APIError
+	var netErr *NetworkError
+	if errors.As(err, &apiErr) || errors.As(err, &netErr) || errors.Is(err, ErrRefreshTokenConsumed) {
+		return err
+	}
+	return &NetworkError{Err: err}
+}
+
+// ScopeError is returned, before anything is sent, when the scopes the user
+// granted are known and don't include the one an action requires. The user
+// must re-authorize the application with the missing scope; see SetScope.
+type ScopeError struct {
+	Action   Action
+	Required Scope
+	Granted  []Scope
+}
+
+func (e *ScopeError) Error() string {
+	return fmt.Sprintf("action %q requires scope %q, but the user only granted %q", e.Action, e.Required, e.Granted)
+}
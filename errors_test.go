@@ -0,0 +1,96 @@
+package withings
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/asymmetricia/withings/enum/status"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAPIError(t *testing.T) {
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(rw, `{"status":401,"body":{},"error":"invalid token"}`)
+	})
+
+	_, err := u.GetBodyMeasures(nil)
+	require.Error(t, err)
+
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr))
+	require.Equal(t, status.Status(401), apiErr.Status)
+	require.Equal(t, "invalid token", apiErr.Message)
+
+	var netErr *NetworkError
+	require.False(t, errors.As(err, &netErr))
+}
+
+func TestNetworkError(t *testing.T) {
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusBadGateway)
+	})
+
+	_, err := u.GetBodyMeasures(nil)
+	require.Error(t, err)
+
+	var netErr *NetworkError
+	require.True(t, errors.As(err, &netErr))
+	require.Equal(t, http.StatusBadGateway, netErr.StatusCode)
+
+	var apiErr *APIError
+	require.False(t, errors.As(err, &apiErr))
+}
+
+func TestNetworkErrorUnreachable(t *testing.T) {
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		hj, ok := rw.(http.Hijacker)
+		require.True(t, ok)
+		conn, _, err := hj.Hijack()
+		require.NoError(t, err)
+		conn.Close()
+	})
+
+	_, err := u.GetBodyMeasures(nil)
+	require.Error(t, err)
+
+	var netErr *NetworkError
+	require.True(t, errors.As(err, &netErr))
+	require.Zero(t, netErr.StatusCode)
+}
+
+func TestStrictEnums(t *testing.T) {
+	bodies := map[string]string{
+		"getmeas":     `{"status":0,"body":{"measuregrps":[{"grpid":1,"date":1636300800,"measures":[{"value":1,"type":9999,"unit":0}]}]}}`,
+		"getworkouts": `{"status":0,"body":{"series":[{"id":1,"category":9999,"date":"2021-01-02"}]}}`,
+		"get":         `{"status":0,"body":{"series":[{"startdate":1609545600,"enddate":1609546200,"state":9}]}}`,
+	}
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(rw, bodies[req.URL.Query().Get("action")])
+	})
+
+	calls := map[string]func() error{
+		"MeasType": func() error {
+			_, err := u.GetBodyMeasures(nil)
+			return err
+		},
+		"WorkoutType": func() error {
+			_, err := u.GetWorkouts(nil)
+			return err
+		},
+		"SleepState": func() error {
+			_, err := u.GetSleepMeasures(nil)
+			return err
+		},
+	}
+	for enum, call := range calls {
+		u.Client.StrictEnums = false
+		require.NoError(t, call(), enum)
+
+		u.Client.StrictEnums = true
+		var enumErr *UnknownEnumError
+		require.ErrorAs(t, call(), &enumErr, enum)
+		require.Equal(t, enum, enumErr.Enum)
+	}
+}
@@ -0,0 +1,36 @@
+package withings
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// flexInt is an integer that decodes from a JSON number or from a string
+// holding one, as Withings occasionally sends numbers as strings. A null
+// leaves it unchanged.
+type flexInt int64
+
+func (f *flexInt) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		i, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return fmt.Errorf("decoding number from string %q: %w", s, err)
+		}
+		*f = flexInt(i)
+		return nil
+	}
+
+	var i int64
+	if err := json.Unmarshal(data, &i); err != nil {
+		return err
+	}
+	*f = flexInt(i)
+	return nil
+}
@@ -0,0 +1,70 @@
+package withings
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/asymmetricia/withings/enum/meastype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNumbersAsStrings(t *testing.T) {
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		require.NoError(t, req.ParseForm())
+		switch req.Form.Get("action") {
+		case "getmeas":
+			fmt.Fprint(rw, `{"status":0,"body":{"measuregrps":[
+				{"grpid":1,"date":"1636300800","created":"1636300860","modified":null,"measures":[
+					{"value":"72345","type":"1","unit":"-3"},
+					{"value":61,"type":11,"unit":0}
+				]}
+			]}}`)
+		case "getworkouts":
+			fmt.Fprint(rw, `{"status":0,"body":{"series":[
+				{"id":1,"startdate":"1609524000","enddate":"1609527600","modified":"1609527660","date":"2021-01-01"}
+			]}}`)
+		case "getsummary":
+			fmt.Fprint(rw, `{"status":0,"body":{"series":[
+				{"id":1,"startdate":"1609470000","enddate":1609498800,"modified":"1609499000","date":"2021-01-01"}
+			]}}`)
+		default:
+			t.Errorf("unexpected action %q", req.Form.Get("action"))
+		}
+	})
+
+	measures, err := u.GetBodyMeasures(nil)
+	require.NoError(t, err)
+	require.Empty(t, measures.Warnings)
+	require.Len(t, measures.Body.MeasureGrps, 1)
+	g := measures.Body.MeasureGrps[0]
+	require.EqualValues(t, 1636300800, g.Date)
+	require.EqualValues(t, 1636300860, g.Created)
+	require.Equal(t, []Measure{
+		{Value: 72345, Type: meastype.Weight, Unit: -3},
+		{Value: 61, Type: meastype.HeartPulseBPM, Unit: 0},
+	}, g.Measures)
+
+	workouts, err := u.GetWorkouts(nil)
+	require.NoError(t, err)
+	w := workouts.Body.Series[0]
+	require.EqualValues(t, 1609524000, w.StartDate)
+	require.EqualValues(t, 1609527600, w.EndDate)
+	require.EqualValues(t, 1609527660, w.Modified)
+	require.EqualValues(t, 1609527600, w.EndDateParsed.Unix())
+
+	sleep, err := u.GetSleepSummary(nil)
+	require.NoError(t, err)
+	s := sleep.Body.Series[0]
+	require.EqualValues(t, 1609470000, s.StartDate)
+	require.EqualValues(t, 1609498800, s.EndDate)
+	require.EqualValues(t, 1609499000, s.Modified)
+}
+
+func TestFlexIntRejectsNonNumbers(t *testing.T) {
+	var f flexInt
+	require.Error(t, f.UnmarshalJSON([]byte(`"abc"`)))
+	require.Error(t, f.UnmarshalJSON([]byte(`1.5`)))
+	require.NoError(t, f.UnmarshalJSON([]byte(`"-3"`)))
+	require.EqualValues(t, -3, f)
+}
@@ -0,0 +1,90 @@
+package withings
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// freshnessWindow is how far back LastDataTimestamps searches categories
+// whose latest record can't be requested directly.
+const freshnessWindow = 365
+
+// LastDataTimestamps returns the time of the most recent record of each of the
+// given categories, such as for showing how long ago each kind of data was last
+// synced. Categories with no data are left out of the result.
+//
+// Withings returns body measures newest first, so only the latest measure group
+// is requested, leaving out objectives such as a target weight. The latest workout is found with GetRecentWorkoutsCtx. Activity
+// and sleep summaries can't be limited to the latest record, so the last year
+// of them is requested at once.
+//
+// The time is that of the measure group for body measures, the end of the
+// workout or night for workouts and sleep summaries, and midnight of the day
+// for activity.
+func (u *User) LastDataTimestamps(ctx context.Context, categories ...Category) (map[Category]time.Time, error) {
+	ctx = WithRequestOptions(ctx, noDataAsError(false), paging())
+
+	now := u.Client.now()
+	y, m, d := now.Date()
+	end := time.Date(y, m, d+1, 0, 0, 0, 0, now.Location())
+
+	latest := map[Category]time.Time{}
+	for _, c := range categories {
+		data := &BackfillData{}
+		var err error
+		switch c {
+		case CategoryBodyMeasures:
+			limit, category := 1, MeasureCategoryReal
+			var r BodyMeasuresResp
+			r, err = u.GetBodyMeasuresCtx(ctx, &BodyMeasuresQueryParams{Limit: &limit, Category: &category, SortDescending: true})
+			if err == nil {
+				data.MeasureGroups = r.Body.MeasureGrps
+			}
+		case CategoryWorkouts:
+			data.Workouts, err = u.GetRecentWorkoutsCtx(ctx, 1)
+		default:
+			fetch, ok := backfillFetchers[c]
+			if !ok {
+				return nil, fmt.Errorf("last data timestamps: unknown category %q", c)
+			}
+			err = fetch(u, ctx, end.AddDate(0, 0, -freshnessWindow), end, data)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("last data timestamps of %s: %w", c, err)
+		}
+
+		if t := data.latest(); !t.IsZero() {
+			latest[c] = t
+		}
+	}
+
+	return latest, nil
+}
+
+// latest returns the time of the most recent record in d, or the zero time if
+// it has none.
+func (d *BackfillData) latest() time.Time {
+	var latest time.Time
+	later := func(t time.Time) {
+		if t.After(latest) {
+			latest = t
+		}
+	}
+
+	for _, g := range d.MeasureGroups {
+		later(g.Time())
+	}
+	for _, a := range d.Activities {
+		if a.ParsedDate != nil {
+			later(*a.ParsedDate)
+		}
+	}
+	for _, w := range d.Workouts {
+		later(time.Unix(w.EndDate, 0))
+	}
+	for _, s := range d.SleepSummaries {
+		later(time.Unix(s.EndDate, 0))
+	}
+	return latest
+}
@@ -0,0 +1,49 @@
+package withings
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLastDataTimestamps(t *testing.T) {
+	weighed := time.Now().AddDate(0, 0, -40).Truncate(time.Second)
+	var measureCalls, sleepCalls int
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		require.NoError(t, req.ParseForm())
+		q := req.Form
+		switch q.Get("action") {
+		case "getmeas":
+			measureCalls++
+			require.Equal(t, "1", q.Get("limit"))
+			require.Equal(t, "1", q.Get("category"))
+			require.Empty(t, q.Get("startdate"))
+			fmt.Fprintf(rw, `{"status":0,"body":{"measuregrps":[
+				{"grpid":2,"date":%d,"measures":[]}
+			]}}`, weighed.Unix())
+		case "getworkouts":
+			fmt.Fprint(rw, `{"status":0,"body":{"series":[{"id":1,"startdate":1609524000,"enddate":1609527600,"date":"2021-01-01"}]}}`)
+		case "getsummary":
+			sleepCalls++
+			fmt.Fprint(rw, `{"status":0,"body":{"series":[]}}`)
+		default:
+			t.Errorf("unexpected action %q", q.Get("action"))
+		}
+	})
+
+	latest, err := u.LastDataTimestamps(context.Background(), CategoryBodyMeasures, CategoryWorkouts, CategorySleepSummary)
+	require.NoError(t, err)
+	require.Len(t, latest, 2)
+	require.True(t, weighed.Equal(latest[CategoryBodyMeasures]))
+	require.Equal(t, int64(1609527600), latest[CategoryWorkouts].Unix())
+
+	require.Equal(t, 1, measureCalls)
+	require.Equal(t, 1, sleepCalls)
+
+	_, err = u.LastDataTimestamps(context.Background(), Category("bogus"))
+	require.Error(t, err)
+}
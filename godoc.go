@@ -15,6 +15,14 @@ You can now use the client to generate the authorization URL the user needs to n
 Using the code returned by the redirect in the query parameters, you can generate a new user. This user struct can be used to immediately perform actions against the users data. It also contains the token you should save somewhere for reuse. Obviously use whatever context you would like here.
 	u, err := client.NewUserFromAuthCode(context.Background(), code)
 
+Command line tools can run the whole flow with InteractiveLogin instead. It listens for the redirect on the given address, opens the authorization URL in the user's browser, checks the state and returns the new user.
+	u, err := client.InteractiveLogin(ctx, "localhost:8888")
+
+Servers can instead handle the redirect with CallbackHandler, which checks the state against the client's StateStore before generating the user. States are kept in memory by default; when the redirect may reach a different instance than the one that generated the URL, set StateStore to one shared between instances.
+	http.Handle("/callback", client.CallbackHandler(func(w http.ResponseWriter, r *http.Request, u *withings.User, err error) {
+		// persist the user's token, or report err
+	}))
+
 Make sure the save at least the refreshToken for accessing the user data at a later date. You may also save the accessToken, but it does expire and creating a new client from saved token data only requires the refreshToken.
 	refreshToken, err := i := u.Token.Token().RefreshToken
 
@@ -22,6 +30,22 @@ Creating User From Saved Token
 
 You can easily create a user from a saved token using the NewUserFromRefreshToken method. A working configured client is required for the user generated from this method to work.
 
+To persist the whole token instead, save the output of TokenJSON and restore the user from it with NewUserFromTokenJSON, which makes no request until the user is used.
+	data, err := u.TokenJSON()
+	u, err = client.NewUserFromTokenJSON(data)
+
+Token Refresh Events
+
+Set TokenRefreshed on the client to be told of every access token refresh, for example to keep an audit trail. The event carries the user id, the old and new expiry and whether the refresh token rotated, but never the token values.
+	client.TokenRefreshed = func(e withings.TokenRefreshEvent) {
+		log.Printf("refreshed token of user %s, expires %s", e.UserID, e.NewExpiry)
+	}
+
+To persist a rotated refresh token as soon as it is issued, set OnTokenRefresh on the user. It is called with the new token after every successful refresh.
+	u.OnTokenRefresh = func(t *oauth2.Token) {
+		save(t.RefreshToken)
+	}
+
 Requesting Data
 
 The user struct has various methods associated with each API endpoint to perform data retrieval. The methods take a specific param struct specifying the api options to use on the request. The API is a bit "special" so the params vary a bit between each method. The client does what it can to smooth those out but there is only so much that can be done.
@@ -52,6 +76,40 @@ Request Timeout
 By default all methods utilize a context to timeout the request to the API. The value of the timeout is stored on the Client and can be access as/set on Client.Timeout. Setting is _not_ thread safe and should only be set on client creation. If you need to change the
 timeout for different requests use the methodCtx variant of the method.
 
+The timeout covers the whole request, including reading the response body. Connecting to the API and waiting for the response headers are separately bounded by the client's Transport, so a large pull such as several days of intraday activity only needs a longer overall deadline. SetTimeouts adjusts both at once.
+	client.SetTimeouts(5*time.Second, 2*time.Minute)
+
+For a single large export, such as several days of intraday activity, use the Ctx variant with a context of its own instead of changing the client's Timeout. To bound each of the requests made by a helper separately rather than the export as a whole, attach the WithTimeout option. The transport created by NewTransport sends TCP keepalives, so NATs and firewalls don't drop the connection as idle while a slow response is pending.
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	samples, err := u.GetIntradayRange(ctx, start, end)
+
+	ctx = withings.WithRequestOptions(context.Background(), withings.WithTimeout(5*time.Minute))
+	samples, err = u.GetIntradayRange(ctx, start, end)
+
+Rate Limiting
+
+Withings limits the number of requests an application may make. To pace requests, set Limiter on the client to anything with a Wait(ctx) method, such as a rate.Limiter from golang.org/x/time/rate. Every request waits for it, including each of the requests made by helpers such as GetIntradayRange.
+	client.Limiter = rate.NewLimiter(rate.Every(time.Second), 5)
+
+If a request is rate limited regardless, all of that user's requests, including concurrent ones, are paused for as long as the response's Retry-After header asks, or a minute if it doesn't say. The rate limited request itself still returns its error, unless MaxRetries is set on the client, in which case requests that read data are retried with exponential backoff starting at BackoffBase. Requests that change state, such as subscribing to notifications, are never retried.
+	client.MaxRetries = 3
+	client.BackoffBase = 500 * time.Millisecond
+
+When the same client serves both interactive and background requests, the retry policy and limiter can be overridden per request with the WithRetries, WithLimiter and SkipLimiter options. When a request whose retry policy is overridden gives up, the user's other requests are only paused if its Retry-After header asks, not for the default minute.
+	ctx = withings.WithRequestOptions(ctx, withings.WithRetries(0, 0), withings.SkipLimiter())
+
+Responses whose headers report the remaining quota carry it in their RateLimit field, which is nil otherwise, so callers can slow down before reaching the limit.
+	if r.RateLimit != nil && r.RateLimit.Remaining < 10 {
+		time.Sleep(time.Until(r.RateLimit.Reset))
+	}
+
+Request Options
+
+Headers can be added to every request by setting ExtraHeaders on the client, or to the requests made with a particular context by attaching RequestOptions to it. The Authorization header set by the client is never overridden.
+	ctx = withings.WithRequestOptions(ctx, withings.WithHeader("X-Request-Id", id))
+	m, err := u.GetBodyMeasuresCtx(ctx, &p)
+
 Oauth2 State Randomization
 
 By default the state generated by the AuthCodeURL utilized crypto/rand. If you would like to implement your own random method you can do so by assigning the function to Rand field of the Client struct. The function should support the Rand type. Also this is _not_ thread safe so only perform this action on client creation.
@@ -60,10 +118,51 @@ Raw Request Data
 
 By default every returned response will be parsed and the parsed data returned. If you need access to the raw request data you can enable it by setting the SaveRawResponse field of the client struct to true. This should be done at client creation time. With it set to true the RawResponse field of the returned structs will include the raw response.
 
+Cached Responses
+
+Every response has a FromCache field. It is set when the response was served by an HTTP cache in the client's Transport rather than by the API, as marked by the X-From-Cache header that caching transports such as httpcache add.
+
+Record And Replay
+
+For developing without constant live calls, RecordTo writes every raw response to a directory, and ReplayFrom serves later requests from those recordings without touching the network.
+	client.RecordTo("testdata/recordings")   // against the live API
+	client.ReplayFrom("testdata/recordings") // offline
+
 Data Helper Methods
 
 Some data request methods include a parseResponse field on the params struct. If this is included additional parsing is performed to make the data more usable. This can be seen on GetBodyMeasures for example.
 
+Empty Results
+
+When a requested window simply contains no data, every Get and List method returns a successful response with a non-nil, empty Body and a nil error. This is the case whether the API responds with an empty series or with an empty body, so callers only need to check the length of the series they are interested in. The NoData field of the response is set in this case.
+
+To handle an empty window as an error instead, make the request with the NoDataAsError option. The method then returns ErrNoData along with the empty response. The GetAll methods only return it when every page was empty.
+	ctx = withings.WithRequestOptions(ctx, withings.NoDataAsError())
+	s, err := u.GetSleepSummaryCtx(ctx, &p)
+	if errors.Is(err, withings.ErrNoData) {
+		// nothing recorded yet
+	}
+
+Errors
+
+Failures to reach the API, including non-2XX HTTP responses, are returned as a *NetworkError and are usually worth retrying. Requests the API processed but rejected carry a *APIError holding the status from the response body, for example an invalid token that calls for re-authorization. Use errors.As to tell them apart.
+	var apiErr *withings.APIError
+	if errors.As(err, &apiErr) && apiErr.Status == status.TokenIsInvalidOrDoesntExist {
+		// re-authorize the user
+	}
+
+API Host
+
+Data and token requests are sent to DefaultBaseURL. To use a different host, such as a regional one or a mock server for tests, set BaseURL on the client at creation time.
+	client.BaseURL = "https://wbsapi.us.withings.net"
+
+The authorization page, and optionally a separate token URL, are set per client by its Endpoint, which defaults to Oauth2Endpoint.
+
+Disconnecting A User
+
+Deauthorize revokes all of a user's notification subscriptions and discards their token, for example when their account is deleted. Withings has no revocation endpoint for the token itself, so it stays valid until it expires.
+	err := u.Deauthorize(ctx)
+
 Include Path Fields In Response
 
 You can include the path fields sent to the API by setting IncludePath to true on the client. This is primarily used for debugging but could be helpful in some situations.
@@ -72,5 +171,7 @@ Oauth2 Scopes
 
 By default the client will request all known scopes. If you would like to pair down this you can change the scope by using the SetScope method of the client. Consts in the form of ScopeXxx are provided to aid selection.
 
+The scopes a user actually granted are recorded with their token and returned by User.Scopes. Data requests whose scope the user is known not to have granted fail early with a *ScopeError naming the missing scope, rather than being sent to the API.
+
 */
 package withings
@@ -0,0 +1,82 @@
+package withings
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	// restingHRWindow is the length of the period RestingHeartRate averages
+	// the heart rate over.
+	restingHRWindow = 30 * time.Minute
+	// restingHRMinSamples is the fewest heart rate samples a period must have
+	// to be considered, so that a few isolated low readings don't count as
+	// sustained.
+	restingHRMinSamples = 5
+)
+
+// RestingHR is a resting heart rate and the period it was computed from.
+type RestingHR struct {
+	// BPM is the average heart rate over the period.
+	BPM float64
+	// Start and End bound the period.
+	Start, End time.Time
+	// Samples is the number of heart rate samples in the period.
+	Samples int
+}
+
+// RestingHeartRate derives the user's resting heart rate on the day of date,
+// midnight to midnight in the location of date, from their intraday heart rate
+// samples. It is the lowest average heart rate over any 30 minute period
+// holding at least 5 samples, which usually falls during sleep. ErrNoData is
+// returned if no period has enough samples, such as on a day the user didn't
+// wear a device that measures heart rate.
+func (u *User) RestingHeartRate(ctx context.Context, date time.Time) (RestingHR, error) {
+	y, m, d := date.Date()
+	start := time.Date(y, m, d, 0, 0, 0, 0, date.Location())
+	end := time.Date(y, m, d+1, 0, 0, 0, 0, date.Location())
+
+	samples, err := u.GetIntradayRange(ctx, start, end)
+	if err != nil {
+		return RestingHR{}, fmt.Errorf("resting heart rate: %w", err)
+	}
+
+	rhr, ok := restingHeartRate(samples)
+	if !ok {
+		return RestingHR{}, fmt.Errorf("resting heart rate on %s: %w", start.Format("2006-01-02"), ErrNoData)
+	}
+	return rhr, nil
+}
+
+// restingHeartRate returns the period of samples, which are ordered by time,
+// with the lowest average heart rate, as described by RestingHeartRate.
+func restingHeartRate(samples []IntradaySample) (RestingHR, bool) {
+	var hr []IntradaySample
+	for _, s := range samples {
+		if s.HeartRate != nil && *s.HeartRate > 0 {
+			hr = append(hr, s)
+		}
+	}
+
+	var best RestingHR
+	found := false
+	sum, j := 0, 0
+	for i := range hr {
+		// Extend the period starting at sample i to every sample within the
+		// window.
+		for ; j < len(hr) && hr[j].Time.Sub(hr[i].Time) < restingHRWindow; j++ {
+			sum += *hr[j].HeartRate
+		}
+
+		if n := j - i; n >= restingHRMinSamples {
+			bpm := float64(sum) / float64(n)
+			if !found || bpm < best.BPM {
+				best = RestingHR{BPM: bpm, Start: hr[i].Time, End: hr[j-1].Time, Samples: n}
+				found = true
+			}
+		}
+		sum -= *hr[i].HeartRate
+	}
+	return best, found
+}
@@ -0,0 +1,57 @@
+package withings
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestingHeartRate(t *testing.T) {
+	day := time.Date(2021, 3, 15, 0, 0, 0, 0, time.UTC)
+	sleepStart := day.Add(3 * time.Hour)
+
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		start, err := strconv.ParseInt(req.URL.Query().Get("startdate"), 10, 64)
+		require.NoError(t, err)
+		require.Equal(t, day.Unix(), start)
+
+		series := map[string]map[string]int{}
+		// Every 5 minutes, 70bpm except for an hour of 52bpm during sleep
+		// and an isolated 40bpm reading.
+		for ts := day; ts.Before(day.Add(24 * time.Hour)); ts = ts.Add(5 * time.Minute) {
+			hr := 70
+			if !ts.Before(sleepStart) && ts.Before(sleepStart.Add(time.Hour)) {
+				hr = 52
+			}
+			if ts.Equal(day.Add(12 * time.Hour)) {
+				hr = 40
+			}
+			series[strconv.FormatInt(ts.Unix(), 10)] = map[string]int{"heart_rate": hr, "duration": 60}
+		}
+		require.NoError(t, json.NewEncoder(rw).Encode(map[string]interface{}{
+			"status": 0,
+			"body":   map[string]interface{}{"series": series},
+		}))
+	})
+
+	rhr, err := u.RestingHeartRate(context.Background(), day.Add(15*time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, float64(52), rhr.BPM)
+	require.Equal(t, sleepStart, rhr.Start.UTC())
+	require.Equal(t, 6, rhr.Samples)
+}
+
+func TestRestingHeartRateNoData(t *testing.T) {
+	hr := 50
+	samples := []IntradaySample{
+		{Time: time.Unix(0, 0), IntraDayActivity: IntraDayActivity{HeartRate: &hr}},
+		{Time: time.Unix(60, 0)},
+	}
+	_, ok := restingHeartRate(samples)
+	require.False(t, ok)
+}
@@ -0,0 +1,114 @@
+package withings
+
+import (
+	"context"
+	"sort"
+	"time"
+)
+
+// ActivityThreshold decides whether an intraday sample counts as active. A
+// sample is active when its step count or its heart rate reaches the
+// corresponding threshold. A zero threshold disables that criterion.
+type ActivityThreshold struct {
+	Steps     int
+	HeartRate int
+}
+
+// active reports whether the sample meets the threshold.
+func (t ActivityThreshold) active(a IntraDayActivity) bool {
+	if t.Steps > 0 && a.Steps != nil && *a.Steps >= t.Steps {
+		return true
+	}
+	if t.HeartRate > 0 && a.HeartRate != nil && *a.HeartRate >= t.HeartRate {
+		return true
+	}
+	return false
+}
+
+// ActiveDuration sums the durations of the samples that are active according
+// to threshold. Samples that don't report a duration don't contribute.
+func (r IntradayActivityResp) ActiveDuration(threshold ActivityThreshold) time.Duration {
+	active, _ := r.activeIdle(threshold)
+	return active
+}
+
+// IdleDuration sums the durations of the samples that are not active according
+// to threshold. Samples that don't report a duration don't contribute.
+func (r IntradayActivityResp) IdleDuration(threshold ActivityThreshold) time.Duration {
+	_, idle := r.activeIdle(threshold)
+	return idle
+}
+
+func (r IntradayActivityResp) activeIdle(threshold ActivityThreshold) (active, idle time.Duration) {
+	if r.Body == nil {
+		return 0, 0
+	}
+
+	for _, a := range r.Body.Series {
+		if a.Duration == nil {
+			continue
+		}
+
+		d := time.Duration(*a.Duration) * time.Second
+		if threshold.active(a) {
+			active += d
+		} else {
+			idle += d
+		}
+	}
+
+	return active, idle
+}
+
+// IntradaySample is a single intraday activity sample and the time it was
+// taken.
+type IntradaySample struct {
+	Time time.Time
+	IntraDayActivity
+}
+
+// intradayChunk is the longest span requested at once by GetIntradayRange.
+// The API returns at most a day of intraday activity per request.
+const intradayChunk = 24 * time.Hour
+
+// GetIntradayRange retrieves the intraday activity between start and end,
+// which may span several days. The range is requested one day at a time, each
+// request waiting for the client's Limiter, and the samples of every request
+// are merged into a single series ordered by time. If ctx is cancelled or times
+// out part way, the samples retrieved so far are returned along with ctx.Err().
+func (u *User) GetIntradayRange(ctx context.Context, start, end time.Time) ([]IntradaySample, error) {
+	samples := map[int64]IntraDayActivity{}
+	ctx = WithRequestOptions(ctx, noDataAsError(false))
+	for chunkStart := start; chunkStart.Before(end); chunkStart = chunkStart.Add(intradayChunk) {
+		chunkEnd := chunkStart.Add(intradayChunk)
+		if chunkEnd.After(end) {
+			chunkEnd = end
+		}
+
+		cs, ce := chunkStart, chunkEnd
+		resp, err := u.GetIntradayActivityCtx(ctx, &IntradayActivityQueryParam{StartDate: &cs, EndDate: &ce})
+		if err != nil {
+			if ctx.Err() != nil && len(samples) > 0 {
+				return intradaySeries(samples), ctx.Err()
+			}
+			return nil, err
+		}
+		for ts, a := range resp.Body.Series {
+			samples[ts] = a
+		}
+	}
+
+	return intradaySeries(samples), nil
+}
+
+// intradaySeries returns the samples, keyed by UNIX time, ordered by time.
+func intradaySeries(samples map[int64]IntraDayActivity) []IntradaySample {
+	series := make([]IntradaySample, 0, len(samples))
+	for ts, a := range samples {
+		series = append(series, IntradaySample{Time: time.Unix(ts, 0), IntraDayActivity: a})
+	}
+	sort.Slice(series, func(i, j int) bool {
+		return series[i].Time.Before(series[j].Time)
+	})
+	return series
+}
@@ -0,0 +1,143 @@
+package withings
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/asymmetricia/withings/enum/intradayfield"
+	"github.com/stretchr/testify/require"
+)
+
+func TestActiveDuration(t *testing.T) {
+	var resp IntradayActivityResp
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"status": 0,
+		"body": {
+			"series": {
+				"1636300800": {"steps": 80, "duration": 60},
+				"1636300860": {"steps": 5, "heart_rate": 130, "duration": 60},
+				"1636300920": {"steps": 2, "heart_rate": 62, "duration": 60},
+				"1636300980": {"heart_rate": 58, "duration": 120},
+				"1636301100": {"steps": 200}
+			}
+		}
+	}`), &resp))
+
+	threshold := ActivityThreshold{Steps: 30, HeartRate: 100}
+	require.Equal(t, 2*time.Minute, resp.ActiveDuration(threshold))
+	require.Equal(t, 3*time.Minute, resp.IdleDuration(threshold))
+
+	stepsOnly := ActivityThreshold{Steps: 30}
+	require.Equal(t, time.Minute, resp.ActiveDuration(stepsOnly))
+	require.Equal(t, 4*time.Minute, resp.IdleDuration(stepsOnly))
+}
+
+// countingLimiter counts the requests it lets through.
+type countingLimiter struct {
+	waits int32
+}
+
+func (l *countingLimiter) Wait(ctx context.Context) error {
+	atomic.AddInt32(&l.waits, 1)
+	return ctx.Err()
+}
+
+func TestGetIntradayRange(t *testing.T) {
+	var windows [][2]int64
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		start, err := strconv.ParseInt(req.URL.Query().Get("startdate"), 10, 64)
+		require.NoError(t, err)
+		end, err := strconv.ParseInt(req.URL.Query().Get("enddate"), 10, 64)
+		require.NoError(t, err)
+		windows = append(windows, [2]int64{start, end})
+
+		// A sample at each end of the window, so the shared boundaries of
+		// consecutive windows are returned twice.
+		fmt.Fprintf(rw, `{"status":0,"body":{"series":{"%d":{"steps":1},"%d":{"steps":2}}}}`, end, start)
+	})
+	limiter := &countingLimiter{}
+	u.Client.Limiter = limiter
+
+	start := time.Unix(1636300800, 0)
+	end := start.Add(60 * time.Hour)
+	series, err := u.GetIntradayRange(context.Background(), start, end)
+	require.NoError(t, err)
+
+	day := int64(24 * 60 * 60)
+	require.Equal(t, [][2]int64{
+		{start.Unix(), start.Unix() + day},
+		{start.Unix() + day, start.Unix() + 2*day},
+		{start.Unix() + 2*day, end.Unix()},
+	}, windows)
+	require.EqualValues(t, 3, atomic.LoadInt32(&limiter.waits))
+
+	require.Len(t, series, 4)
+	for i := 1; i < len(series); i++ {
+		require.True(t, series[i-1].Time.Before(series[i].Time))
+	}
+	require.Equal(t, start, series[0].Time)
+	require.Equal(t, end, series[3].Time)
+}
+
+// cancellingLimiter cancels a context once it has let a number of requests
+// through.
+type cancellingLimiter struct {
+	after  int
+	cancel context.CancelFunc
+}
+
+func (l *cancellingLimiter) Wait(ctx context.Context) error {
+	if l.after == 0 {
+		l.cancel()
+	}
+	l.after--
+	return ctx.Err()
+}
+
+func TestGetIntradayRangeCancelled(t *testing.T) {
+	var calls int
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		calls++
+		start := req.URL.Query().Get("startdate")
+		fmt.Fprintf(rw, `{"status":0,"body":{"series":{"%s":{"steps":1}}}}`, start)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	u.Client.Limiter = &cancellingLimiter{after: 1, cancel: cancel}
+
+	start := time.Unix(1636300800, 0)
+	series, err := u.GetIntradayRange(ctx, start, start.Add(60*time.Hour))
+	require.ErrorIs(t, err, context.Canceled)
+	require.Equal(t, 1, calls)
+	require.Len(t, series, 1)
+	require.Equal(t, start, series[0].Time)
+}
+
+func TestIntradayDataFields(t *testing.T) {
+	var calls int
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		calls++
+		require.Equal(t, "steps,heart_rate,spo2_auto", req.URL.Query().Get("data_fields"))
+		fmt.Fprint(rw, `{"status":0,"body":{"series":{"1636300800":{"steps":12,"heart_rate":88,"spo2_auto":97.5}}}}`)
+	})
+
+	r, err := u.GetIntradayActivity(&IntradayActivityQueryParam{
+		DataFields: []intradayfield.Field{intradayfield.Steps, intradayfield.HeartRate, intradayfield.SpO2Auto},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 97.5, *r.Body.Series[1636300800].SpO2Auto)
+
+	_, err = u.GetIntradayActivity(&IntradayActivityQueryParam{
+		DataFields: []intradayfield.Field{intradayfield.Steps, "heartrate"},
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "heartrate")
+	require.Equal(t, 1, calls)
+}
@@ -0,0 +1,113 @@
+package withings
+
+import (
+	"reflect"
+	"time"
+)
+
+// timeIn returns a copy of t in loc, or nil if t is nil.
+func timeIn(t *time.Time, loc *time.Location) *time.Time {
+	if t == nil {
+		return nil
+	}
+	in := t.In(loc)
+	return &in
+}
+
+// InLocation returns a copy of the response with every parsed time expressed
+// in loc rather than in the timezone of its record, e.g. to merge several
+// users' data into one timeline. The instants themselves are unchanged.
+func (r ActivitiesMeasuresResp) InLocation(loc *time.Location) ActivitiesMeasuresResp {
+	if r.Body == nil {
+		return r
+	}
+
+	body := *r.Body
+	body.ParsedDate = timeIn(body.ParsedDate, loc)
+	body.Activities = append([]Activity(nil), body.Activities...)
+	for i := range body.Activities {
+		body.Activities[i].ParsedDate = timeIn(body.Activities[i].ParsedDate, loc)
+	}
+	r.Body = &body
+	return r
+}
+
+// InLocation returns a copy of the response with every parsed time expressed
+// in loc rather than in the timezone of its record.
+func (r WorkoutResponse) InLocation(loc *time.Location) WorkoutResponse {
+	if r.Body == nil {
+		return r
+	}
+
+	body := *r.Body
+	body.Series = append([]Workout(nil), body.Series...)
+	for i := range body.Series {
+		w := &body.Series[i]
+		w.StartDateParsed = timeIn(w.StartDateParsed, loc)
+		w.EndDateParsed = timeIn(w.EndDateParsed, loc)
+		w.DateParsed = timeIn(w.DateParsed, loc)
+	}
+	r.Body = &body
+	return r
+}
+
+// InLocation returns a copy of the response with every parsed time expressed
+// in loc rather than in the timezone of its record.
+func (r SleepSummaryResp) InLocation(loc *time.Location) SleepSummaryResp {
+	if r.Body == nil {
+		return r
+	}
+
+	body := *r.Body
+	body.Series = append([]SleepSummary(nil), body.Series...)
+	for i := range body.Series {
+		s := &body.Series[i]
+		s.StartDateParsed = timeIn(s.StartDateParsed, loc)
+		s.EndDateParsed = timeIn(s.EndDateParsed, loc)
+		s.DateParsed = timeIn(s.DateParsed, loc)
+	}
+	r.Body = &body
+	return r
+}
+
+// InLocation returns a copy of the response with every parsed time expressed
+// in loc.
+func (r SleepMeasuresResp) InLocation(loc *time.Location) SleepMeasuresResp {
+	if r.Body == nil {
+		return r
+	}
+
+	body := *r.Body
+	body.Series = append([]SleepMeasure(nil), body.Series...)
+	for i := range body.Series {
+		m := &body.Series[i]
+		m.StartDateParsed = timeIn(m.StartDateParsed, loc)
+		m.EndDateParsed = timeIn(m.EndDateParsed, loc)
+	}
+	r.Body = &body
+	return r
+}
+
+// InLocation returns a copy of the response with the dates of the parsed
+// response, if any, expressed in loc.
+func (r BodyMeasuresResp) InLocation(loc *time.Location) BodyMeasuresResp {
+	if r.ParsedResponse == nil {
+		return r
+	}
+
+	// ParseData builds new slices, so the copy shares nothing with r.
+	parsed := r.ParseData()
+	timeType := reflect.TypeOf(time.Time{})
+	v := reflect.ValueOf(parsed).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		series := v.Field(i)
+		for j := 0; j < series.Len(); j++ {
+			date := series.Index(j).FieldByName("Date")
+			if date.IsValid() && date.Type() == timeType {
+				date.Set(reflect.ValueOf(date.Interface().(time.Time).In(loc)))
+			}
+		}
+	}
+	r.ParsedResponse = parsed
+	return r
+}
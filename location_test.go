@@ -0,0 +1,48 @@
+package withings
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInLocation(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+	paris, err := time.LoadLocation("Europe/Paris")
+	require.NoError(t, err)
+
+	start := time.Date(2021, 1, 2, 23, 0, 0, 0, paris)
+	end := start.Add(8 * time.Hour)
+	sleep := SleepSummaryResp{Body: &SleepSummaryBody{Series: []SleepSummary{
+		{ID: 1, StartDateParsed: &start, EndDateParsed: &end},
+	}}}
+
+	inTokyo := sleep.InLocation(tokyo)
+	require.Equal(t, tokyo, inTokyo.Body.Series[0].StartDateParsed.Location())
+	require.True(t, start.Equal(*inTokyo.Body.Series[0].StartDateParsed))
+	require.Nil(t, inTokyo.Body.Series[0].DateParsed)
+	require.Equal(t, paris, sleep.Body.Series[0].StartDateParsed.Location())
+
+	workouts := WorkoutResponse{Body: &WorkoutRespBody{Series: []Workout{{StartDateParsed: &start}}}}
+	require.Equal(t, tokyo, workouts.InLocation(tokyo).Body.Series[0].StartDateParsed.Location())
+
+	activities := ActivitiesMeasuresResp{Body: &ActivitiesMeasuresRespBody{Activities: []Activity{{ParsedDate: &start}}}}
+	require.Equal(t, tokyo, activities.InLocation(tokyo).Body.Activities[0].ParsedDate.Location())
+
+	measures := SleepMeasuresResp{Body: &SleepMeasuresRespBody{Series: []SleepMeasure{{EndDateParsed: &end}}}}
+	require.Equal(t, tokyo, measures.InLocation(tokyo).Body.Series[0].EndDateParsed.Location())
+
+	body := BodyMeasuresResp{Body: &BodyMeasureRespBody{MeasureGrps: []MeasureGroup{
+		{GrpID: 1, Date: start.Unix(), Measures: []Measure{{Value: 72345, Type: 1, Unit: -3}, {Value: 61, Type: 11}}},
+	}}}
+	body.ParsedResponse = body.ParseData()
+	converted := body.InLocation(tokyo)
+	require.Equal(t, tokyo, converted.ParsedResponse.Weights[0].Date.Location())
+	require.Equal(t, tokyo, converted.ParsedResponse.HeartPulses[0].Date.Location())
+	require.True(t, start.Equal(converted.ParsedResponse.Weights[0].Date))
+	require.NotEqual(t, tokyo, body.ParsedResponse.Weights[0].Date.Location())
+
+	require.Nil(t, SleepSummaryResp{}.InLocation(tokyo).Body)
+}
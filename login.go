@@ -0,0 +1,103 @@
+package withings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+// openBrowser opens url in the user's browser. It is a variable so tests can
+// stand in for the browser.
+var openBrowser = func(url string) error {
+	switch runtime.GOOS {
+	case "darwin":
+		return exec.Command("open", url).Start()
+	case "windows":
+		return exec.Command("rundll32", "url.dll,FileProtocolHandler", url).Start()
+	default:
+		return exec.Command("xdg-open", url).Start()
+	}
+}
+
+// InteractiveLogin runs the whole authorization flow for command line tools.
+// It listens on bindAddr for the redirect, prints the authorization URL to
+// standard error and tries to open it in the user's browser, then waits for
+// the redirect and generates the user from it as per NewUserFromRedirect, so
+// the client must have a StateStore. The client's redirect URL must lead to
+// bindAddr; if bindAddr is empty, the host of the redirect URL is used. It
+// gives up when ctx is done.
+func (c *Client) InteractiveLogin(ctx context.Context, bindAddr string) (*User, error) {
+	if c.StateStore == nil {
+		return nil, errors.New("interactive login: client has no StateStore")
+	}
+
+	redirect, err := url.Parse(c.OAuth2Config.RedirectURL)
+	if err != nil {
+		return nil, fmt.Errorf("interactive login: parsing redirect URL: %w", err)
+	}
+	if bindAddr == "" {
+		bindAddr = redirect.Host
+	}
+	path := redirect.Path
+	if path == "" {
+		path = "/"
+	}
+
+	authURL, _, err := c.AuthCodeURL()
+	if err != nil {
+		return nil, fmt.Errorf("interactive login: generating state: %w", err)
+	}
+
+	l, err := net.Listen("tcp", bindAddr)
+	if err != nil {
+		return nil, fmt.Errorf("interactive login: %w", err)
+	}
+
+	type result struct {
+		user *User
+		err  error
+	}
+	results := make(chan result, 1)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(path, func(rw http.ResponseWriter, req *http.Request) {
+		var res result
+		res.user, res.err = c.NewUserFromRedirect(ctx, req)
+
+		rw.Header().Set("content-type", "text/plain")
+		if res.err != nil {
+			rw.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintln(rw, res.err)
+		} else {
+			fmt.Fprintln(rw, "ok! close this window.")
+		}
+
+		select {
+		case results <- res:
+		default:
+		}
+	})
+
+	srv := &http.Server{Handler: mux}
+	go srv.Serve(l)
+	defer srv.Close()
+
+	fmt.Fprintf(os.Stderr, "Open this URL to authorize access:\n%s\n", authURL)
+	_ = openBrowser(authURL)
+
+	select {
+	case <-ctx.Done():
+		return nil, fmt.Errorf("interactive login: %w", ctx.Err())
+	case res := <-results:
+		if res.err != nil {
+			return nil, fmt.Errorf("interactive login: %w", res.err)
+		}
+		return res.user, nil
+	}
+}
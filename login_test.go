@@ -0,0 +1,72 @@
+package withings
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInteractiveLogin(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		body, err := io.ReadAll(req.Body)
+		require.NoError(t, err)
+		form, err := url.ParseQuery(string(body))
+		require.NoError(t, err)
+		require.Equal(t, "the-code", form.Get("code"))
+		fmt.Fprint(rw, `{"status":0,"body":{"userid":1234,"access_token":"access","refresh_token":"refresh","expires_in":10800,"token_type":"Bearer"}}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	// Find a free port for the redirect.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := l.Addr().String()
+	require.NoError(t, l.Close())
+
+	c := NewClient("client-id", "client-secret", "http://"+addr+"/callback")
+	c.BaseURL = srv.URL
+
+	// The browser follows the authorization URL back to the redirect.
+	var tamper bool
+	defer func(open func(string) error) { openBrowser = open }(openBrowser)
+	openBrowser = func(raw string) error {
+		authURL, err := url.Parse(raw)
+		if err != nil {
+			return err
+		}
+		state := authURL.Query().Get("state")
+		if tamper {
+			state = "forged"
+		}
+		go func() {
+			res, err := http.Get(authURL.Query().Get("redirect_uri") + "?" + url.Values{"code": {"the-code"}, "state": {state}}.Encode())
+			if err == nil {
+				res.Body.Close()
+			}
+		}()
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	u, err := c.InteractiveLogin(ctx, addr)
+	require.NoError(t, err)
+	require.Equal(t, "access", u.OauthToken.AccessToken)
+
+	tamper = true
+	_, err = c.InteractiveLogin(ctx, "")
+	require.ErrorIs(t, err, ErrInvalidState)
+
+	c.StateStore = nil
+	_, err = c.InteractiveLogin(ctx, "")
+	require.Error(t, err)
+}
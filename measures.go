@@ -0,0 +1,386 @@
+package withings
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/asymmetricia/withings/enum/meastype"
+)
+
+// GroupByDay buckets the measure groups by the calendar day on which they were
+// taken, keyed as YYYY-MM-DD. Each group is placed using its own timezone,
+// falling back to the timezone of the response body and then UTC, so readings
+// either side of a DST change still land on the correct local day.
+func (rm BodyMeasuresResp) GroupByDay() map[string][]MeasureGroup {
+	days := map[string][]MeasureGroup{}
+	if rm.Body == nil {
+		return days
+	}
+
+	for _, g := range rm.Body.MeasureGrps {
+		loc := measureGroupLocation(g, rm.Body.Timezone)
+		day := time.Unix(g.Date, 0).In(loc).Format("2006-01-02")
+		days[day] = append(days[day], g)
+	}
+
+	return days
+}
+
+// GroupByDevice buckets the measure groups by the DeviceID of the device that
+// took them, in the order they appear in the response, e.g. to keep a
+// separate sync cursor per device. Groups not taken by a device are keyed by
+// the empty string.
+func (rm BodyMeasuresResp) GroupByDevice() map[string][]MeasureGroup {
+	devices := map[string][]MeasureGroup{}
+	if rm.Body == nil {
+		return devices
+	}
+
+	for _, g := range rm.Body.MeasureGrps {
+		devices[g.DeviceID] = append(devices[g.DeviceID], g)
+	}
+
+	return devices
+}
+
+// measureGroupLocation returns the location the group was recorded in. The
+// group's own timezone is preferred, then fallback, then UTC.
+func measureGroupLocation(g MeasureGroup, fallback string) *time.Location {
+	for _, name := range []string{g.Timezone, fallback} {
+		if name == "" {
+			continue
+		}
+		if loc, err := loadLocation(name); err == nil {
+			return loc
+		}
+	}
+	return time.UTC
+}
+
+// FilterByModel returns the measure groups taken by devices of the given
+// model, in the order they appear in the response. Use it to attribute
+// readings to one of several devices of the same type, such as two different
+// scales in a household.
+func (rm BodyMeasuresResp) FilterByModel(modelID int) []MeasureGroup {
+	if rm.Body == nil {
+		return nil
+	}
+
+	var groups []MeasureGroup
+	for _, g := range rm.Body.MeasureGrps {
+		if g.ModelID == modelID {
+			groups = append(groups, g)
+		}
+	}
+	return groups
+}
+
+// LatestByType returns the most recent real value of each of the given measure
+// types, fetched with a single body measures request. Types the user has no
+// data for are omitted from the map.
+func (u *User) LatestByType(ctx context.Context, types ...meastype.MeasType) (map[meastype.MeasType]Measurement, error) {
+	latest := map[meastype.MeasType]Measurement{}
+	if len(types) == 0 {
+		return latest, nil
+	}
+
+	category := MeasureCategoryReal
+	ctx = WithRequestOptions(ctx, noDataAsError(false))
+	resp, err := u.GetBodyMeasuresCtx(ctx, &BodyMeasuresQueryParams{
+		MeasTypes: types,
+		Category:  &category,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := map[meastype.MeasType]bool{}
+	for _, t := range types {
+		wanted[t] = true
+	}
+	for _, g := range resp.Body.MeasureGrps {
+		for _, m := range g.Measures {
+			if !wanted[m.Type] {
+				continue
+			}
+			if prev, ok := latest[m.Type]; ok && !g.Time().After(prev.Date) {
+				continue
+			}
+			latest[m.Type] = Measurement{
+				Date:     g.Time(),
+				Value:    m.Float(),
+				Attrib:   g.Attrib,
+				Category: g.Category,
+			}
+		}
+	}
+
+	return latest, nil
+}
+
+// BMI returns the body mass index computed from the latest real weight in the
+// response and the given height in meters. It returns ErrNoWeight if the
+// response has no weight measure.
+func (rm BodyMeasuresResp) BMI(heightM float64) (float64, error) {
+	if heightM <= 0 {
+		return 0, fmt.Errorf("computing BMI: invalid height %v m", heightM)
+	}
+	if rm.Body == nil {
+		return 0, fmt.Errorf("computing BMI: %w", ErrNoWeight)
+	}
+
+	var weight float64
+	var latest int64
+	found := false
+	for _, g := range rm.Body.MeasureGrps {
+		if g.Category == MeasureCategoryObjective || (found && g.Date <= latest) {
+			continue
+		}
+		if w, ok := g.Measure(meastype.Weight); ok {
+			weight, latest, found = w, g.Date, true
+		}
+	}
+	if !found {
+		return 0, fmt.Errorf("computing BMI: %w", ErrNoWeight)
+	}
+	return weight / (heightM * heightM), nil
+}
+
+// BMI returns the user's body mass index, computed from their latest weight
+// and latest height, fetched with a single body measures request. It returns
+// ErrNoHeight or ErrNoWeight if the user has no such measure.
+func (u *User) BMI(ctx context.Context) (float64, error) {
+	latest, err := u.LatestByType(ctx, meastype.Weight, meastype.Height)
+	if err != nil {
+		return 0, err
+	}
+
+	height, ok := latest[meastype.Height]
+	if !ok {
+		return 0, fmt.Errorf("computing BMI: %w", ErrNoHeight)
+	}
+	weight, ok := latest[meastype.Weight]
+	if !ok {
+		return 0, fmt.Errorf("computing BMI: %w", ErrNoWeight)
+	}
+	if height.Value <= 0 {
+		return 0, fmt.Errorf("computing BMI: invalid height %v m", height.Value)
+	}
+	return weight.Value / (height.Value * height.Value), nil
+}
+
+// sortDescending orders the measure groups newest first.
+func (rm BodyMeasuresResp) sortDescending() {
+	if rm.Body == nil {
+		return
+	}
+
+	grps := rm.Body.MeasureGrps
+	sort.SliceStable(grps, func(i, j int) bool {
+		return grps[i].Date > grps[j].Date
+	})
+}
+
+// MergeSessions combines measure groups that share a GrpID into a single group
+// holding the measures of all of them. Withings occasionally splits one
+// session, such as the weight and fat ratio of a single weigh-in, across
+// several groups with the same ID. The merged groups are returned in the order
+// in which each ID first appears; identical measures are only kept once.
+func (rm BodyMeasuresResp) MergeSessions() []MeasureGroup {
+	if rm.Body == nil {
+		return nil
+	}
+
+	var sessions []MeasureGroup
+	index := map[int]int{}
+	for _, g := range rm.Body.MeasureGrps {
+		i, ok := index[g.GrpID]
+		if !ok {
+			index[g.GrpID] = len(sessions)
+			g.Measures = append([]Measure(nil), g.Measures...)
+			sessions = append(sessions, g)
+			continue
+		}
+
+	measures:
+		for _, m := range g.Measures {
+			for _, existing := range sessions[i].Measures {
+				if existing == m {
+					continue measures
+				}
+			}
+			sessions[i].Measures = append(sessions[i].Measures, m)
+		}
+	}
+
+	return sessions
+}
+
+// NextLastUpdate returns the value to pass as LastUpdate on the next
+// incremental query. It is the latest time at which any returned group was
+// created or modified. Withings only returns groups changed after lastupdate,
+// so nothing seen in this response is returned again. If the response holds no
+// groups, the server's update time of the response is returned instead.
+func (rm BodyMeasuresResp) NextLastUpdate() time.Time {
+	if rm.Body == nil {
+		return time.Time{}
+	}
+
+	var last int64
+	for _, g := range rm.Body.MeasureGrps {
+		if g.Created > last {
+			last = g.Created
+		}
+		if g.Modified > last {
+			last = g.Modified
+		}
+	}
+
+	if last == 0 {
+		if rm.Body.Updatetime == 0 {
+			return time.Time{}
+		}
+		last = rm.Body.Updatetime
+	}
+	return time.Unix(last, 0)
+}
+
+// Series returns the measures of type t as parallel slices of times and real
+// values, oldest first, in the shape expected by most plotting libraries.
+// Values are converted as per Measure.Float.
+func (rm BodyMeasuresResp) Series(t meastype.MeasType) ([]time.Time, []float64) {
+	if rm.Body == nil {
+		return nil, nil
+	}
+
+	type point struct {
+		date  int64
+		value float64
+	}
+	var points []point
+	for _, g := range rm.Body.MeasureGrps {
+		for _, m := range g.Measures {
+			if m.Type == t {
+				points = append(points, point{g.Date, m.Float()})
+			}
+		}
+	}
+	sort.SliceStable(points, func(i, j int) bool {
+		return points[i].date < points[j].date
+	})
+
+	times := make([]time.Time, len(points))
+	values := make([]float64, len(points))
+	for i, p := range points {
+		times[i] = time.Unix(p.date, 0)
+		values[i] = p.value
+	}
+	return times, values
+}
+
+// AvailableTypes returns the distinct measure types present across all groups,
+// in ascending order, e.g. to offer charts only for the metrics a user has
+// data for.
+func (rm BodyMeasuresResp) AvailableTypes() []meastype.MeasType {
+	if rm.Body == nil {
+		return nil
+	}
+
+	seen := map[meastype.MeasType]bool{}
+	var types []meastype.MeasType
+	for _, g := range rm.Body.MeasureGrps {
+		for _, m := range g.Measures {
+			if !seen[m.Type] {
+				seen[m.Type] = true
+				types = append(types, m.Type)
+			}
+		}
+	}
+	sort.Slice(types, func(i, j int) bool {
+		return types[i] < types[j]
+	})
+	return types
+}
+
+// MeasureQuery selects body measures for GetMeasures. The zero value selects
+// every measure of the user.
+type MeasureQuery struct {
+	// Types restricts the measures to the given types. If empty, all types
+	// are returned.
+	Types []meastype.MeasType
+	// Category is MeasureCategoryReal or MeasureCategoryObjective to return
+	// only real measures or only objectives. If zero, both are returned.
+	Category int
+	// Start and End bound the time the measures were taken. Either may be
+	// zero to leave that side unbounded.
+	Start, End time.Time
+	// LastUpdate, if not zero, selects the measures added or changed since
+	// then instead, and can't be combined with Start and End.
+	LastUpdate time.Time
+	// PageSize is the number of measure groups requested at a time, at most
+	// MaxBodyMeasuresLimit. If zero, DefaultBodyMeasuresLimit is used.
+	PageSize int
+	// Offset resumes paging from an offset previously returned in
+	// BodyMeasureRespBody.Offset.
+	Offset int
+}
+
+// validate reports the first problem with the query, if any.
+func (q MeasureQuery) validate() error {
+	switch {
+	case q.Category != 0 && q.Category != MeasureCategoryReal && q.Category != MeasureCategoryObjective:
+		return fmt.Errorf("unknown measure category %d", q.Category)
+	case !q.LastUpdate.IsZero() && (!q.Start.IsZero() || !q.End.IsZero()):
+		return fmt.Errorf("LastUpdate can't be combined with Start or End")
+	case !q.Start.IsZero() && !q.End.IsZero() && q.End.Before(q.Start):
+		return fmt.Errorf("end %s is before start %s", q.End, q.Start)
+	case q.PageSize < 0 || q.PageSize > MaxBodyMeasuresLimit:
+		return fmt.Errorf("page size %d is not between 0 and %d", q.PageSize, MaxBodyMeasuresLimit)
+	case q.Offset < 0:
+		return fmt.Errorf("negative offset %d", q.Offset)
+	}
+	return nil
+}
+
+// params returns the BodyMeasuresQueryParams equivalent to the query.
+func (q MeasureQuery) params() *BodyMeasuresQueryParams {
+	p := &BodyMeasuresQueryParams{MeasTypes: q.Types}
+	if q.Category != 0 {
+		category := q.Category
+		p.Category = &category
+	}
+	if !q.Start.IsZero() {
+		start := q.Start
+		p.StartDate = &start
+	}
+	if !q.End.IsZero() {
+		end := q.End
+		p.EndDate = &end
+	}
+	if !q.LastUpdate.IsZero() {
+		lastUpdate := q.LastUpdate
+		p.LastUpdate = &lastUpdate
+	}
+	if q.PageSize != 0 {
+		limit := q.PageSize
+		p.Limit = &limit
+	}
+	if q.Offset != 0 {
+		offset := q.Offset
+		p.Offset = &offset
+	}
+	return p
+}
+
+// GetMeasures retrieves all body measures selected by the query, following
+// every page as per GetAllBodyMeasuresCtx. It is the recommended way to query
+// body measures; the query is checked before anything is sent, so invalid
+// combinations fail early rather than being rejected or ignored by the API.
+func (u *User) GetMeasures(ctx context.Context, query MeasureQuery) (BodyMeasuresResp, error) {
+	if err := query.validate(); err != nil {
+		return BodyMeasuresResp{}, fmt.Errorf("invalid measure query: %w", err)
+	}
+	return u.GetAllBodyMeasuresCtx(ctx, query.params())
+}
@@ -0,0 +1,522 @@
+package withings
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/asymmetricia/withings/enum/meastype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroupByDay(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+
+	resp := BodyMeasuresResp{
+		Body: &BodyMeasureRespBody{
+			Timezone: "America/New_York",
+			MeasureGrps: []MeasureGroup{
+				// 23:30 local on the night DST ends, and the next morning.
+				{GrpID: 1, Date: time.Date(2021, 11, 6, 23, 30, 0, 0, ny).Unix()},
+				{GrpID: 2, Date: time.Date(2021, 11, 7, 7, 0, 0, 0, ny).Unix()},
+				// Recorded while travelling; already the next day in Tokyo.
+				{GrpID: 3, Date: time.Date(2021, 11, 7, 11, 0, 0, 0, ny).Unix(), Timezone: "Asia/Tokyo"},
+			},
+		},
+	}
+
+	days := resp.GroupByDay()
+	require.Len(t, days, 3)
+	require.Equal(t, 1, days["2021-11-06"][0].GrpID)
+	require.Equal(t, 2, days["2021-11-07"][0].GrpID)
+	require.Equal(t, 3, days["2021-11-08"][0].GrpID)
+
+	require.Empty(t, BodyMeasuresResp{}.GroupByDay())
+}
+
+func TestGetAllBodyMeasuresUsesServerOffset(t *testing.T) {
+	var offsets []string
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		offsets = append(offsets, req.URL.Query().Get("offset"))
+		require.Equal(t, "10", req.URL.Query().Get("limit"))
+
+		switch req.URL.Query().Get("offset") {
+		case "":
+			fmt.Fprint(rw, `{"status":0,"body":{"more":1,"offset":37,"measuregrps":[
+				{"grpid":1,"date":1636300800,"measures":[{"value":72345,"type":1,"unit":-3}]}
+			]}}`)
+		case "37":
+			fmt.Fprint(rw, `{"status":0,"body":{"more":0,"offset":0,"measuregrps":[
+				{"grpid":2,"date":1636387200,"measures":[{"value":72100,"type":1,"unit":-3}]}
+			]}}`)
+		default:
+			t.Errorf("unexpected offset %q", req.URL.Query().Get("offset"))
+		}
+	})
+
+	limit := 10
+	resp, err := u.GetAllBodyMeasuresCtx(context.Background(), &BodyMeasuresQueryParams{
+		Limit:          &limit,
+		ParseResponse:  true,
+		SortDescending: true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"", "37"}, offsets)
+
+	require.Len(t, resp.Body.MeasureGrps, 2)
+	require.Equal(t, 2, resp.Body.MeasureGrps[0].GrpID)
+	require.Len(t, resp.ParsedResponse.Weights, 2)
+	require.InDelta(t, 72.1, resp.ParsedResponse.Weights[0].Kgs, 0.0001)
+}
+
+func TestBodyMeasuresContinuation(t *testing.T) {
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(rw, `{"status":0,"body":{"more":1,"offset":37,"measuregrps":[]}}`)
+	})
+
+	resp, err := u.GetBodyMeasures(nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, resp.Body.More)
+	require.Equal(t, 37, resp.Body.Offset)
+}
+
+func TestGetAllBodyMeasuresStalledOffset(t *testing.T) {
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(rw, `{"status":0,"body":{"more":1,"offset":0,"measuregrps":[]}}`)
+	})
+
+	_, err := u.GetAllBodyMeasuresCtx(context.Background(), nil)
+	require.Error(t, err)
+}
+
+func TestMergeSessions(t *testing.T) {
+	resp := BodyMeasuresResp{
+		Body: &BodyMeasureRespBody{
+			MeasureGrps: []MeasureGroup{
+				{GrpID: 7, Date: 100, Measures: []Measure{{Value: 72345, Type: meastype.Weight, Unit: -3}}},
+				{GrpID: 8, Date: 200, Measures: []Measure{{Value: 61, Type: meastype.HeartPulseBPM}}},
+				{GrpID: 7, Date: 100, Measures: []Measure{
+					{Value: 72345, Type: meastype.Weight, Unit: -3},
+					{Value: 215, Type: meastype.FatRatio, Unit: -1},
+				}},
+			},
+		},
+	}
+
+	sessions := resp.MergeSessions()
+	require.Len(t, sessions, 2)
+	require.Equal(t, 7, sessions[0].GrpID)
+	require.Len(t, sessions[0].Measures, 2)
+	require.Equal(t, meastype.MeasType(meastype.FatRatio), sessions[0].Measures[1].Type)
+	require.Equal(t, 8, sessions[1].GrpID)
+
+	// The response itself is left untouched.
+	require.Len(t, resp.Body.MeasureGrps[0].Measures, 1)
+}
+
+func TestNextLastUpdate(t *testing.T) {
+	resp := BodyMeasuresResp{
+		Body: &BodyMeasureRespBody{
+			Updatetime: 5000,
+			MeasureGrps: []MeasureGroup{
+				{GrpID: 1, Date: 100, Created: 150, Modified: 150},
+				{GrpID: 2, Date: 200, Created: 250, Modified: 900},
+				{GrpID: 3, Date: 300, Created: 350, Modified: 350},
+			},
+		},
+	}
+	require.Equal(t, time.Unix(900, 0), resp.NextLastUpdate())
+
+	resp.Body.MeasureGrps = nil
+	require.Equal(t, time.Unix(5000, 0), resp.NextLastUpdate())
+
+	require.True(t, BodyMeasuresResp{}.NextLastUpdate().IsZero())
+}
+
+func TestLastUpdateParam(t *testing.T) {
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		require.Equal(t, "900", req.URL.Query().Get("lastupdate"))
+		require.Empty(t, req.URL.Query().Get("enddate"))
+		fmt.Fprint(rw, `{"status":0,"body":{}}`)
+	})
+
+	last := time.Unix(900, 0)
+	_, err := u.GetBodyMeasures(&BodyMeasuresQueryParams{LastUpdate: &last})
+	require.NoError(t, err)
+}
+
+// multiMetricScaleReading is a single weigh-in from a body composition scale.
+const multiMetricScaleReading = `{"status":0,"body":{"updatetime":1636387300,"timezone":"Europe/Paris","measuregrps":[
+	{"grpid":42,"attrib":0,"date":1636387200,"created":1636387210,"category":1,"measures":[
+		{"value":72345,"type":1,"unit":-3},
+		{"value":215,"type":6,"unit":-1},
+		{"value":5402,"type":76,"unit":-2},
+		{"value":3981,"type":77,"unit":-2},
+		{"value":287,"type":88,"unit":-2},
+		{"value":712,"type":91,"unit":-2}
+	]}
+]}}`
+
+func TestMultiMetricScaleReading(t *testing.T) {
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(rw, multiMetricScaleReading)
+	})
+
+	resp, err := u.GetBodyMeasures(&BodyMeasuresQueryParams{ParseResponse: true})
+	require.NoError(t, err)
+	require.Len(t, resp.Body.MeasureGrps, 1)
+	g := resp.Body.MeasureGrps[0]
+
+	tests := []struct {
+		typ  meastype.MeasType
+		name string
+		unit string
+		want float64
+	}{
+		{meastype.Weight, "Weight", "kg", 72.345},
+		{meastype.FatRatio, "FatRatio", "%", 21.5},
+		{meastype.MuscleMass, "MuscleMass", "kg", 54.02},
+		{meastype.Hydration, "Hydration", "kg", 39.81},
+		{meastype.BoneMass, "BoneMass", "kg", 2.87},
+		{meastype.PulseWaveVelocity, "PulseWaveVelocity", "m/s", 7.12},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.name, tt.typ.String())
+		unit, _ := meastype.CanonicalUnit(tt.typ)
+		require.Equal(t, tt.unit, unit)
+
+		v, ok := g.Measure(tt.typ)
+		require.True(t, ok, tt.name)
+		require.InDelta(t, tt.want, v, 0.0001, tt.name)
+	}
+
+	_, ok := g.Measure(meastype.Height)
+	require.False(t, ok)
+
+	parsed := resp.ParsedResponse
+	require.Len(t, parsed.MuscleMasses, 1)
+	require.InDelta(t, 54.02, parsed.MuscleMasses[0].Mass, 0.0001)
+	require.Len(t, parsed.Hydration, 1)
+	require.InDelta(t, 39.81, parsed.Hydration[0].Hydration, 0.0001)
+	require.Len(t, parsed.BoneMasses, 1)
+	require.InDelta(t, 2.87, parsed.BoneMasses[0].Mass, 0.0001)
+	require.Len(t, parsed.PulseWaveVelocity, 1)
+	require.InDelta(t, 7.12, parsed.PulseWaveVelocity[0].Velocity, 0.0001)
+}
+
+func TestSeries(t *testing.T) {
+	resp := BodyMeasuresResp{
+		Body: &BodyMeasureRespBody{
+			MeasureGrps: []MeasureGroup{
+				{Date: 300, Measures: []Measure{{Value: 71900, Type: meastype.Weight, Unit: -3}}},
+				{Date: 100, Measures: []Measure{
+					{Value: 72345, Type: meastype.Weight, Unit: -3},
+					{Value: 215, Type: meastype.FatRatio, Unit: -1},
+				}},
+				{Date: 200, Measures: []Measure{{Value: 61, Type: meastype.HeartPulseBPM}}},
+			},
+		},
+	}
+
+	times, values := resp.Series(meastype.Weight)
+	require.Equal(t, []time.Time{time.Unix(100, 0), time.Unix(300, 0)}, times)
+	require.Len(t, values, 2)
+	require.InDelta(t, 72.345, values[0], 0.0001)
+	require.InDelta(t, 71.9, values[1], 0.0001)
+
+	times, values = resp.Series(meastype.Height)
+	require.Empty(t, times)
+	require.Empty(t, values)
+}
+
+func TestRealMeasuresOfType(t *testing.T) {
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		q := req.URL.Query()
+		require.Equal(t, "getmeas", q.Get("action"))
+		require.Equal(t, "1", q.Get("meastype"))
+		require.Equal(t, "1", q.Get("category"))
+		fmt.Fprint(rw, `{"status":0,"body":{"measuregrps":[
+			{"grpid":1,"date":1636300800,"category":1,"measures":[{"value":72345,"type":1,"unit":-3}]}
+		]}}`)
+	})
+
+	p := RealMeasuresOfType(meastype.Weight)
+	p.ParseResponse = true
+	resp, err := u.GetBodyMeasures(p)
+	require.NoError(t, err)
+	require.Len(t, resp.ParsedResponse.Weights, 1)
+	require.Equal(t, MeasureCategoryReal, resp.ParsedResponse.Weights[0].Category)
+}
+
+func TestMalformedMeasuresAreSkipped(t *testing.T) {
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(rw, `{"status":0,"body":{"updatetime":1636387300,"measuregrps":[
+			{"grpid":42,"date":1636387200,"measures":[
+				{"value":72345,"type":1,"unit":-3},
+				{"value":"corrupt","type":6,"unit":-1},
+				{"value":5402,"type":76,"unit":-2}
+			]},
+			{"grpid":"corrupt","date":1636387300,"measures":[]},
+			{"grpid":43,"date":1636387400,"measures":[{"value":61,"type":11,"unit":0}]}
+		]}}`)
+	})
+
+	resp, err := u.GetBodyMeasures(&BodyMeasuresQueryParams{ParseResponse: true})
+	require.NoError(t, err)
+	require.EqualValues(t, 1636387300, resp.Body.Updatetime)
+	require.Len(t, resp.Body.MeasureGrps, 2)
+	require.Len(t, resp.Body.MeasureGrps[0].Measures, 2)
+	require.Len(t, resp.Warnings, 2)
+
+	require.Len(t, resp.ParsedResponse.Weights, 1)
+	require.Len(t, resp.ParsedResponse.MuscleMasses, 1)
+	require.Len(t, resp.ParsedResponse.HeartPulses, 1)
+	require.Empty(t, resp.ParsedResponse.FatRatios)
+}
+
+func TestBodyMeasuresLimit(t *testing.T) {
+	var limits []string
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		limits = append(limits, req.URL.Query().Get("limit"))
+		fmt.Fprint(rw, `{"status":0,"body":{}}`)
+	})
+
+	resp, err := u.GetBodyMeasures(nil)
+	require.NoError(t, err)
+	require.Empty(t, resp.Warnings)
+
+	limit := 5000
+	resp, err = u.GetBodyMeasures(&BodyMeasuresQueryParams{Limit: &limit})
+	require.NoError(t, err)
+	require.Len(t, resp.Warnings, 1)
+
+	limit = 10
+	resp, err = u.GetBodyMeasures(&BodyMeasuresQueryParams{Limit: &limit})
+	require.NoError(t, err)
+	require.Empty(t, resp.Warnings)
+
+	require.Equal(t, []string{"1000", "1000", "10"}, limits)
+}
+
+func TestAvailableTypes(t *testing.T) {
+	resp := BodyMeasuresResp{
+		Body: &BodyMeasureRespBody{
+			MeasureGrps: []MeasureGroup{
+				{Measures: []Measure{{Type: meastype.HeartPulseBPM}, {Type: meastype.Weight}}},
+				{Measures: []Measure{{Type: meastype.Weight}, {Type: meastype.FatRatio}}},
+			},
+		},
+	}
+	require.Equal(t, []meastype.MeasType{meastype.Weight, meastype.FatRatio, meastype.HeartPulseBPM}, resp.AvailableTypes())
+	require.Empty(t, BodyMeasuresResp{}.AvailableTypes())
+}
+
+func TestFilterByModel(t *testing.T) {
+	var body BodyMeasureRespBody
+	require.NoError(t, json.Unmarshal([]byte(`{"measuregrps":[
+		{"grpid":1,"date":1636300800,"modelid":5,"measures":[{"value":72345,"type":1,"unit":-3}]},
+		{"grpid":2,"date":1636300900,"modelid":13,"measures":[{"value":58100,"type":1,"unit":-3}]},
+		{"grpid":3,"date":1636387200,"modelid":5,"measures":[{"value":72100,"type":1,"unit":-3}]}
+	]}`), &body))
+
+	resp := BodyMeasuresResp{Body: &body}
+	groups := resp.FilterByModel(5)
+	require.Len(t, groups, 2)
+	require.Equal(t, 1, groups[0].GrpID)
+	require.Equal(t, 3, groups[1].GrpID)
+	require.Empty(t, resp.FilterByModel(99))
+	require.Empty(t, BodyMeasuresResp{}.FilterByModel(5))
+}
+
+func TestLatestByType(t *testing.T) {
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		q := req.URL.Query()
+		require.Equal(t, "getmeas", q.Get("action"))
+		require.Equal(t, "1,6,11", q.Get("meastypes"))
+		require.Equal(t, "1", q.Get("category"))
+		fmt.Fprint(rw, `{"status":0,"body":{"measuregrps":[
+			{"grpid":1,"date":1636300800,"category":1,"measures":[{"value":72345,"type":1,"unit":-3},{"value":1843,"type":6,"unit":-2}]},
+			{"grpid":2,"date":1636387200,"category":1,"measures":[{"value":72100,"type":1,"unit":-3},{"value":76,"type":4,"unit":0}]},
+			{"grpid":3,"date":1636214400,"category":1,"measures":[{"value":73000,"type":1,"unit":-3}]}
+		]}}`)
+	})
+
+	latest, err := u.LatestByType(context.Background(), meastype.Weight, meastype.FatRatio, meastype.HeartPulseBPM)
+	require.NoError(t, err)
+	require.Len(t, latest, 2)
+	require.InDelta(t, 72.1, latest[meastype.Weight].Value, 1e-9)
+	require.EqualValues(t, 1636387200, latest[meastype.Weight].Date.Unix())
+	require.InDelta(t, 18.43, latest[meastype.FatRatio].Value, 1e-9)
+
+	none, err := u.LatestByType(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, none)
+}
+
+func TestConvertUnits(t *testing.T) {
+	tests := []struct {
+		value, unit int
+		want        float64
+	}{
+		{72345, -3, 72.345},
+		{-72345, -3, -72.345},
+		{-152, -2, -1.52},
+		{-5, -1, -0.5},
+		{3653, -2, 36.53},
+		{-1, -9, -0.000000001},
+		{17, 0, 17},
+		{-17, 0, -17},
+		{-3, 2, -300},
+		{0, -3, 0},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.want, convertUnits(tt.value, tt.unit), "%d * 10^%d", tt.value, tt.unit)
+	}
+
+	var g MeasureGroup
+	require.NoError(t, json.Unmarshal([]byte(`{"measures":[{"value":-42,"type":71,"unit":-2}]}`), &g))
+	v, ok := g.Measure(meastype.BodyTemperature)
+	require.True(t, ok)
+	require.Equal(t, -0.42, v)
+}
+
+func TestBMI(t *testing.T) {
+	var body string
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		require.Equal(t, "1,4", req.URL.Query().Get("meastypes"))
+		fmt.Fprint(rw, body)
+	})
+
+	body = `{"status":0,"body":{"measuregrps":[
+		{"grpid":1,"date":1500000000,"category":1,"measures":[{"value":180,"type":4,"unit":-2}]},
+		{"grpid":2,"date":1636300800,"category":1,"measures":[{"value":81000,"type":1,"unit":-3}]},
+		{"grpid":3,"date":1636214400,"category":1,"measures":[{"value":90000,"type":1,"unit":-3}]}
+	]}}`
+	bmi, err := u.BMI(context.Background())
+	require.NoError(t, err)
+	require.InDelta(t, 25.0, bmi, 1e-9)
+
+	body = `{"status":0,"body":{"measuregrps":[
+		{"grpid":2,"date":1636300800,"category":1,"measures":[{"value":81000,"type":1,"unit":-3}]}
+	]}}`
+	_, err = u.BMI(context.Background())
+	require.ErrorIs(t, err, ErrNoHeight)
+
+	var resp BodyMeasuresResp
+	require.NoError(t, json.Unmarshal([]byte(`{"body":{"measuregrps":[
+		{"grpid":1,"date":1636300800,"category":1,"measures":[{"value":81000,"type":1,"unit":-3}]},
+		{"grpid":2,"date":1636387200,"category":2,"measures":[{"value":70000,"type":1,"unit":-3}]}
+	]}}`), &resp))
+	bmi, err = resp.BMI(1.8)
+	require.NoError(t, err)
+	require.InDelta(t, 25.0, bmi, 1e-9)
+
+	_, err = BodyMeasuresResp{}.BMI(1.8)
+	require.ErrorIs(t, err, ErrNoWeight)
+	_, err = resp.BMI(0)
+	require.Error(t, err)
+}
+
+func TestGroupByDevice(t *testing.T) {
+	var resp BodyMeasuresResp
+	require.NoError(t, json.Unmarshal([]byte(`{"body":{"measuregrps":[
+		{"grpid":1,"date":1636300800,"deviceid":"a1b2","measures":[{"value":72345,"type":1,"unit":-3}]},
+		{"grpid":2,"date":1636300900,"deviceid":"c3d4","measures":[{"value":58100,"type":1,"unit":-3}]},
+		{"grpid":3,"date":1636387200,"deviceid":"a1b2","measures":[{"value":72100,"type":1,"unit":-3}]},
+		{"grpid":4,"date":1636387300,"deviceid":null,"measures":[{"value":180,"type":4,"unit":-2}]}
+	]}}`), &resp))
+
+	devices := resp.GroupByDevice()
+	require.Len(t, devices, 3)
+	require.Len(t, devices["a1b2"], 2)
+	require.Equal(t, 3, devices["a1b2"][1].GrpID)
+	require.Len(t, devices["c3d4"], 1)
+	require.Equal(t, 4, devices[""][0].GrpID)
+	require.Empty(t, BodyMeasuresResp{}.GroupByDevice())
+}
+
+func TestMeasureGroupComment(t *testing.T) {
+	var resp BodyMeasuresResp
+	require.NoError(t, json.Unmarshal([]byte(`{"body":{"measuregrps":[
+		{"grpid":1,"date":1636300800,"measures":[{"value":72345,"type":1,"unit":-3}]},
+		{"grpid":2,"date":1636387200,"comment":"after the holidays","measures":[{"value":72100,"type":1,"unit":-3}]}
+	]}}`), &resp))
+	require.Empty(t, resp.Body.MeasureGrps[0].Comment)
+	require.Equal(t, "after the holidays", resp.Body.MeasureGrps[1].Comment)
+}
+
+func TestGetMeasures(t *testing.T) {
+	var queries []url.Values
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		q := req.URL.Query()
+		queries = append(queries, q)
+		if q.Get("offset") == "5" {
+			fmt.Fprint(rw, `{"status":0,"body":{"more":1,"offset":9,"measuregrps":[
+				{"grpid":1,"date":1636300800,"measures":[{"value":72345,"type":1,"unit":-3}]}
+			]}}`)
+			return
+		}
+		fmt.Fprint(rw, `{"status":0,"body":{"more":0,"offset":0,"measuregrps":[
+			{"grpid":2,"date":1636387200,"measures":[{"value":72100,"type":1,"unit":-3}]}
+		]}}`)
+	})
+
+	start := time.Unix(1636000000, 0)
+	resp, err := u.GetMeasures(context.Background(), MeasureQuery{
+		Types:    []meastype.MeasType{meastype.Weight, meastype.FatRatio},
+		Category: MeasureCategoryReal,
+		Start:    start,
+		PageSize: 50,
+		Offset:   5,
+	})
+	require.NoError(t, err)
+	require.Len(t, resp.Body.MeasureGrps, 2)
+	require.Len(t, queries, 2)
+	require.Equal(t, "1,6", queries[0].Get("meastypes"))
+	require.Equal(t, "1", queries[0].Get("category"))
+	require.Equal(t, "1636000000", queries[0].Get("startdate"))
+	require.Empty(t, queries[0].Get("enddate"))
+	require.Equal(t, "50", queries[0].Get("limit"))
+	require.Equal(t, "9", queries[1].Get("offset"))
+
+	for _, q := range []MeasureQuery{
+		{Category: 3},
+		{Start: start, LastUpdate: start},
+		{Start: start, End: start.Add(-time.Hour)},
+		{PageSize: MaxBodyMeasuresLimit + 1},
+		{Offset: -1},
+	} {
+		_, err := u.GetMeasures(context.Background(), q)
+		require.Error(t, err, "%+v", q)
+	}
+	require.Len(t, queries, 2)
+}
+
+func TestGetAllBodyMeasuresCancelledReturnsPartial(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Query().Get("offset") == "" {
+			fmt.Fprint(rw, `{"status":0,"body":{"more":1,"offset":37,"measuregrps":[
+				{"grpid":1,"date":1636300800,"measures":[{"value":72345,"type":1,"unit":-3}]}
+			]}}`)
+			return
+		}
+		// Cancel while the second page is pending.
+		cancel()
+		<-req.Context().Done()
+	})
+
+	resp, err := u.GetAllBodyMeasuresCtx(ctx, &BodyMeasuresQueryParams{ParseResponse: true})
+	require.ErrorIs(t, err, context.Canceled)
+	require.Len(t, resp.Body.MeasureGrps, 1)
+	require.Len(t, resp.ParsedResponse.Weights, 1)
+	require.True(t, resp.Truncated())
+	require.Equal(t, 37, resp.Body.Offset)
+}
@@ -0,0 +1,32 @@
+package withings
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// newTestUser returns a user with a valid access token whose API requests are
+// all served by handler.
+func newTestUser(t *testing.T, handler http.HandlerFunc) *User {
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	c := NewClient("client-id", "client-secret", "http://localhost:8888")
+	c.BaseURL = srv.URL
+	u := &User{
+		Client: &c,
+		OauthToken: &oauth2.Token{
+			AccessToken:  "access-token",
+			RefreshToken: "refresh-token",
+			TokenType:    "Bearer",
+			Expiry:       time.Now().Add(time.Hour),
+		},
+	}
+	u.HTTPClient = &http.Client{Transport: u}
+
+	return u
+}
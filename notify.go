@@ -0,0 +1,23 @@
+package withings
+
+import (
+	"context"
+	"sort"
+)
+
+// NotificationsByExpiry lists all of the user's notification subscriptions,
+// soonest to expire first, e.g. to renew those about to lapse. Withings
+// returns every subscription in one response, so no paging is needed.
+func (u *User) NotificationsByExpiry(ctx context.Context) ([]NotificationProfile, error) {
+	ctx = WithRequestOptions(ctx, noDataAsError(false))
+	resp, err := u.ListNotificationsCtx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	profiles := append([]NotificationProfile(nil), resp.Body.Profiles...)
+	sort.SliceStable(profiles, func(i, j int) bool {
+		return profiles[i].Expires < profiles[j].Expires
+	})
+	return profiles, nil
+}
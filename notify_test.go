@@ -0,0 +1,280 @@
+package withings
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListNotificationsFiltersAppli(t *testing.T) {
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		require.Equal(t, "list", req.URL.Query().Get("action"))
+		require.Equal(t, "1", req.URL.Query().Get("appli"))
+		fmt.Fprint(rw, `{"status":0,"body":{"profiles":[
+			{"appli":1,"callbackurl":"https://example.com/weight","expires":2147483647,"comment":"weight"},
+			{"appli":44,"callbackurl":"https://example.com/sleep","expires":2147483647,"comment":"sleep"},
+			{"appli":1,"callbackurl":"https://example.com/other","expires":1636387200,"comment":"other"}
+		]}}`)
+	})
+
+	appli := 1
+	resp, err := u.ListNotifications(&ListNotificationsParam{Appli: &appli})
+	require.NoError(t, err)
+	require.Len(t, resp.Body.Profiles, 2)
+	require.Equal(t, "https://example.com/weight", resp.Body.Profiles[0].CallbackURL)
+	require.Equal(t, "https://example.com/other", resp.Body.Profiles[1].CallbackURL)
+	require.EqualValues(t, 1636387200, resp.Body.Profiles[1].ExpiresParsed.Unix())
+}
+
+func TestGetNotificationInformation(t *testing.T) {
+	expires := time.Now().Add(24 * time.Hour).Unix()
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		require.Equal(t, "/notify", req.URL.Path)
+		require.Equal(t, "get", req.URL.Query().Get("action"))
+		require.Equal(t, "1", req.URL.Query().Get("appli"))
+		fmt.Fprintf(rw, `{"status":0,"body":{"appli":1,"callbackurl":"https://example.com/hook","expires":%d,"comment":"weight"}}`, expires)
+	})
+
+	cb, err := url.Parse("https://example.com/hook")
+	require.NoError(t, err)
+	appli := 1
+	resp, err := u.GetNotificationInformation(&NotificationInfoParam{CallbackURL: *cb, Appli: &appli})
+	require.NoError(t, err)
+	require.Equal(t, 1, resp.Body.Appli)
+	require.Equal(t, "https://example.com/hook", resp.Body.CallbackURL)
+	require.Equal(t, expires, resp.Body.ExpiresParsed.Unix())
+	require.False(t, resp.Body.ExpiresWithin(time.Hour))
+	require.True(t, resp.Body.ExpiresWithin(48*time.Hour))
+
+	// Expiry is judged by the API's clock, here a day ahead of the local one.
+	atomic.StoreInt64(&u.Client.state.skew, int64(24*time.Hour))
+	require.True(t, resp.Body.ExpiresWithin(time.Hour))
+}
+
+func TestDeauthorize(t *testing.T) {
+	var revoked []string
+	fail := "https://example.com/broken"
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		require.NoError(t, req.ParseForm())
+		q := req.Form
+		switch q.Get("action") {
+		case "list":
+			fmt.Fprint(rw, `{"status":0,"body":{"profiles":[
+				{"appli":1,"callbackurl":"https://example.com/weight","expires":2147483647},
+				{"appli":44,"callbackurl":"https://example.com/broken","expires":2147483647}
+			]}}`)
+		case "revoke":
+			if q.Get("callbackurl") == fail {
+				fmt.Fprint(rw, `{"status":2555,"error":"unknown"}`)
+				return
+			}
+			revoked = append(revoked, q.Get("appli")+" "+q.Get("callbackurl"))
+			fmt.Fprint(rw, `{"status":0}`)
+		}
+	})
+
+	err := u.Deauthorize(context.Background())
+	var deauthErr *DeauthorizeError
+	require.ErrorAs(t, err, &deauthErr)
+	require.Len(t, deauthErr.Errs, 1)
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	require.EqualValues(t, 2555, apiErr.Status)
+	require.Equal(t, []string{"1 https://example.com/weight"}, revoked)
+	require.NotEmpty(t, u.OauthToken.AccessToken)
+
+	fail = ""
+	revoked = nil
+	require.NoError(t, u.Deauthorize(context.Background()))
+	require.Len(t, revoked, 2)
+	require.Empty(t, u.OauthToken.AccessToken)
+}
+
+func TestDeauthorizeWithoutSubscriptions(t *testing.T) {
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(rw, `{"status":0,"body":{"profiles":[]}}`)
+	})
+
+	ctx := WithRequestOptions(context.Background(), NoDataAsError())
+	require.NoError(t, u.Deauthorize(ctx))
+	require.Empty(t, u.OauthToken.AccessToken)
+}
+
+func TestLongCallbackURLIsPosted(t *testing.T) {
+	cb, err := url.Parse("https://example.com/hook?sig=" + strings.Repeat("a", 3*1024))
+	require.NoError(t, err)
+
+	var methods []string
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		methods = append(methods, req.Method)
+		require.Empty(t, req.URL.RawQuery)
+		require.NoError(t, req.ParseForm())
+		require.Equal(t, cb.String(), req.PostForm.Get("callbackurl"))
+		fmt.Fprintf(rw, `{"status":0,"body":{"appli":1,"callbackurl":%q,"expires":2147483647}}`, cb.String())
+	})
+
+	created, err := u.CreateNotification(&CreateNotificationParam{CallbackURL: *cb, Appli: 1, Comment: "weight"})
+	require.NoError(t, err)
+	require.Len(t, created.Warnings, 1)
+	require.Contains(t, created.Warnings[0], "sent as POST")
+
+	appli := 1
+	info, err := u.GetNotificationInformation(&NotificationInfoParam{CallbackURL: *cb, Appli: &appli})
+	require.NoError(t, err)
+	require.Equal(t, cb.String(), info.Body.CallbackURL)
+	require.Len(t, info.Warnings, 1)
+	require.Contains(t, info.Warnings[0], "sent as POST")
+
+	require.Equal(t, []string{"POST", "POST"}, methods)
+}
+
+func TestCheckCallbackReachable(t *testing.T) {
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		t.Error("the API must not be called")
+	})
+
+	var methods []string
+	callback := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		methods = append(methods, req.Method)
+		require.Empty(t, req.Header.Get("Authorization"))
+		switch req.URL.Path {
+		case "/hook":
+		case "/get-only":
+			if req.Method == "HEAD" {
+				rw.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		default:
+			rw.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(callback.Close)
+
+	check := func(path string) error {
+		cb, err := url.Parse(callback.URL + path)
+		require.NoError(t, err)
+		return u.CheckCallbackReachable(context.Background(), *cb)
+	}
+
+	require.NoError(t, check("/hook"))
+	require.Equal(t, []string{"HEAD"}, methods)
+
+	methods = nil
+	require.NoError(t, check("/get-only"))
+	require.Equal(t, []string{"HEAD", "GET"}, methods)
+
+	err := check("/missing")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "404")
+
+	addr := callback.URL
+	callback.Close()
+	cb, err := url.Parse(addr + "/hook")
+	require.NoError(t, err)
+	err = u.CheckCallbackReachable(context.Background(), *cb)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "unreachable")
+}
+
+func TestFetchAppli(t *testing.T) {
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/measure":
+			fmt.Fprint(rw, `{"status":0,"body":{"measuregrps":[]}}`)
+		case "/v2/measure":
+			fmt.Fprint(rw, `{"status":0,"body":{"activities":[]}}`)
+		case "/v2/sleep":
+			fmt.Fprint(rw, `{"status":0,"body":{"series":[]}}`)
+		case "/v2/heart":
+			fmt.Fprint(rw, `{"status":0,"body":{"series":[]}}`)
+		default:
+			t.Errorf("unexpected request to %s", req.URL.Path)
+		}
+	})
+
+	ctx := context.Background()
+	start, end := time.Now().Add(-time.Hour), time.Now()
+
+	r, err := u.FetchAppli(ctx, AppliWeight, start, end)
+	require.NoError(t, err)
+	require.IsType(t, BodyMeasuresResp{}, r)
+
+	r, err = u.FetchAppli(ctx, AppliActivity, start, end)
+	require.NoError(t, err)
+	require.IsType(t, ActivitiesMeasuresResp{}, r)
+
+	r, err = u.FetchAppli(ctx, AppliSleep, start, end)
+	require.NoError(t, err)
+	require.IsType(t, SleepSummaryResp{}, r)
+
+	r, err = u.FetchAppli(ctx, AppliECG, start, end)
+	require.NoError(t, err)
+	require.IsType(t, HeartListResp{}, r)
+
+	_, err = u.FetchAppli(ctx, AppliBedIn, start, end)
+	require.ErrorIs(t, err, ErrUnsupportedAppli)
+}
+
+func TestSubscribeReliably(t *testing.T) {
+	callback := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {}))
+	t.Cleanup(callback.Close)
+	cb, err := url.Parse(callback.URL + "/hook")
+	require.NoError(t, err)
+
+	var subscribes, gets int
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		require.NoError(t, req.ParseForm())
+		switch req.Form.Get("action") {
+		case "subscribe":
+			subscribes++
+			fmt.Fprint(rw, `{"status":0,"body":{}}`)
+		case "get":
+			gets++
+			// The first subscription is silently dropped.
+			if subscribes < 2 {
+				fmt.Fprint(rw, `{"status":2554,"error":"Not found"}`)
+				return
+			}
+			fmt.Fprintf(rw, `{"status":0,"body":{"appli":1,"callbackurl":%q,"expires":2147483647}}`, cb.String())
+		default:
+			t.Errorf("unexpected action %q", req.Form.Get("action"))
+		}
+	})
+	u.Client.Transport = callback.Client().Transport
+
+	_, err = u.SubscribeReliably(context.Background(), &CreateNotificationParam{CallbackURL: *cb, Appli: 1})
+	require.NoError(t, err)
+	require.Equal(t, 2, subscribes)
+	require.Equal(t, 2, gets)
+
+	insecure := *cb
+	insecure.Scheme = "http"
+	_, err = u.SubscribeReliably(context.Background(), &CreateNotificationParam{CallbackURL: insecure, Appli: 1})
+	require.Error(t, err)
+	require.Equal(t, 2, subscribes)
+}
+
+func TestNotificationsByExpiry(t *testing.T) {
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		require.Equal(t, "list", req.URL.Query().Get("action"))
+		fmt.Fprint(rw, `{"status":0,"body":{"profiles":[
+			{"appli":1,"callbackurl":"https://example.com/weight","expires":2147483647},
+			{"appli":44,"callbackurl":"https://example.com/sleep","expires":"1636387200"},
+			{"appli":16,"callbackurl":"https://example.com/activity","expires":1700000000}
+		]}}`)
+	})
+
+	profiles, err := u.NotificationsByExpiry(context.Background())
+	require.NoError(t, err)
+	require.Len(t, profiles, 3)
+	require.Equal(t, []int{44, 16, 1}, []int{profiles[0].Appli, profiles[1].Appli, profiles[2].Appli})
+	require.EqualValues(t, 1636387200, profiles[0].ExpiresParsed.Unix())
+	require.EqualValues(t, 2147483647, profiles[2].ExpiresParsed.Unix())
+}
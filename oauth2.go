@@ -1,11 +1,55 @@
 package withings
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
 	"golang.org/x/oauth2"
 )
 
-// Endpoint is Withing's OAuth 2.0 endpoint.
+// Oauth2Endpoint is Withing's OAuth 2.0 endpoint, and the default Endpoint of
+// clients created by NewClient. Changing it only affects clients created
+// afterwards; set Client.Endpoint to configure a single client.
 var Oauth2Endpoint = oauth2.Endpoint{
 	AuthURL:  "https://account.withings.com/oauth2_user/authorize2",
-	TokenURL: "https://wbsapi.withings.net/v2/oauth2",
+	TokenURL: DefaultBaseURL + tokenPath,
+}
+
+// tokenResponse is the body of a successful requesttoken action, once
+// unwrapped by WithingsRoundTripper.
+type tokenResponse struct {
+	UserId       UserId `json:"userid"`
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int    `json:"expires_in"`
+	Scope        string `json:"scope"`
+	CsrfToken    string `json:"csrf_token"`
+	TokenType    string `json:"token_type"`
+}
+
+// validate returns an error if the token can't be used by this package. Only
+// Bearer tokens, matched case-insensitively, are supported by the oauth2
+// transport. As in the oauth2 package, an empty type is taken to be Bearer.
+func (r tokenResponse) validate() error {
+	if r.TokenType != "" && !strings.EqualFold(r.TokenType, "Bearer") {
+		return fmt.Errorf("unsupported token type %q, expected Bearer", r.TokenType)
+	}
+	return nil
+}
+
+// token converts the response, received at now, into an oauth2 token. The
+// granted scope and the user id are kept as the token's "scope" and "userid"
+// extras.
+func (r tokenResponse) token(now time.Time) *oauth2.Token {
+	t := &oauth2.Token{
+		AccessToken:  r.AccessToken,
+		TokenType:    r.TokenType,
+		RefreshToken: r.RefreshToken,
+		Expiry:       now.Add(time.Duration(r.ExpiresIn) * time.Second),
+	}
+	return t.WithExtra(map[string]interface{}{
+		"scope":  r.Scope,
+		"userid": string(r.UserId),
+	})
 }
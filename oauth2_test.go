@@ -0,0 +1,111 @@
+package withings
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func TestAuthCodeURLForceConsent(t *testing.T) {
+	c := NewClient("client-id", "client-secret", "http://localhost:8888")
+
+	raw, state, err := c.AuthCodeURLForceConsent()
+	require.NoError(t, err)
+	u, err := url.Parse(raw)
+	require.NoError(t, err)
+	require.Equal(t, "consent", u.Query().Get("prompt"))
+	require.Equal(t, state, u.Query().Get("state"))
+
+	raw, _, err = c.AuthCodeURL(oauth2.SetAuthURLParam("mode", "demo"))
+	require.NoError(t, err)
+	u, err = url.Parse(raw)
+	require.NoError(t, err)
+	require.Equal(t, "demo", u.Query().Get("mode"))
+	require.Empty(t, u.Query().Get("prompt"))
+}
+
+func TestNewUserFromAuthCodeContext(t *testing.T) {
+	started := make(chan struct{}, 1)
+	var delay int64
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		// Reading the body lets the server notice the client going away.
+		_, _ = io.Copy(io.Discard, req.Body)
+		started <- struct{}{}
+		select {
+		case <-time.After(time.Duration(atomic.LoadInt64(&delay))):
+		case <-req.Context().Done():
+			return
+		}
+		fmt.Fprint(rw, `{"status":0,"body":{"userid":1234,"access_token":"access","refresh_token":"refresh","expires_in":10800,"token_type":"Bearer"}}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	c := NewClient("client-id", "client-secret", "http://localhost:8888")
+	c.BaseURL = srv.URL
+
+	// Cancelling the context aborts the exchange.
+	atomic.StoreInt64(&delay, int64(time.Minute))
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+	_, err := c.NewUserFromAuthCode(ctx, "code")
+	require.ErrorIs(t, err, context.Canceled)
+
+	// A context deadline longer than the client's Timeout is honored.
+	atomic.StoreInt64(&delay, int64(50*time.Millisecond))
+	c.Timeout = 10 * time.Millisecond
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	u, err := c.NewUserFromAuthCode(ctx, "code")
+	require.NoError(t, err)
+	require.Equal(t, "access", u.OauthToken.AccessToken)
+	<-started
+
+	// Without a deadline, the client's Timeout applies.
+	_, err = c.NewUserFromAuthCode(context.Background(), "code")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestClientEndpoint(t *testing.T) {
+	var tokenPaths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		tokenPaths = append(tokenPaths, req.URL.Path)
+		fmt.Fprint(rw, `{"status":0,"body":{"userid":1234,"access_token":"access","refresh_token":"refresh","expires_in":10800,"token_type":"Bearer"}}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	a := NewClient("client-id", "client-secret", "http://localhost:8888")
+	b := NewClient("client-id", "client-secret", "http://localhost:8888")
+	b.Endpoint = oauth2.Endpoint{
+		AuthURL:  "https://auth.example.com/authorize",
+		TokenURL: srv.URL + "/custom/token",
+	}
+
+	raw, _, err := a.AuthCodeURL()
+	require.NoError(t, err)
+	require.Contains(t, raw, Oauth2Endpoint.AuthURL)
+	raw, _, err = b.AuthCodeURL()
+	require.NoError(t, err)
+	require.Contains(t, raw, "https://auth.example.com/authorize")
+	require.Equal(t, "https://account.withings.com/oauth2_user/authorize2", Oauth2Endpoint.AuthURL)
+
+	_, err = b.NewUserFromAuthCode(context.Background(), "code")
+	require.NoError(t, err)
+
+	// A default token URL follows BaseURL.
+	a.BaseURL = srv.URL
+	_, err = a.NewUserFromAuthCode(context.Background(), "code")
+	require.NoError(t, err)
+	require.Equal(t, []string{"/custom/token", "/v2/oauth2"}, tokenPaths)
+}
@@ -0,0 +1,37 @@
+package withings
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	dir := t.TempDir()
+
+	calls := 0
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		calls++
+		fmt.Fprint(rw, `{"status":0,"body":{"measuregrps":[
+			{"grpid":1,"date":1636300800,"measures":[{"value":72345,"type":1,"unit":-3}]}
+		]}}`)
+	})
+	require.NoError(t, u.Client.RecordTo(dir))
+
+	recorded, err := u.GetBodyMeasures(nil)
+	require.NoError(t, err)
+	require.FileExists(t, filepath.Join(dir, "measure_getmeas.json"))
+
+	u.Client.ReplayFrom(dir)
+	replayed, err := u.GetBodyMeasures(nil)
+	require.NoError(t, err)
+	require.Equal(t, 1, calls)
+	require.Equal(t, recorded.Body.MeasureGrps, replayed.Body.MeasureGrps)
+
+	_, err = u.GetSleepSummary(nil)
+	require.Error(t, err)
+	require.Equal(t, 1, calls)
+}
@@ -0,0 +1,451 @@
+package withings
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/asymmetricia/withings/enum/status"
+)
+
+// idempotentActions lists the actions that only read data. They are sent as
+// GET requests and may safely be sent again should a request fail. Every other
+// action changes state on the server, such as creating or revoking a
+// notification, and is sent as a POST request that must never be retried
+// automatically.
+var idempotentActions = map[Action]bool{
+	ActionGetIntradayActivity: true,
+	ActionGetActivity:         true,
+	ActionGetWorkouts:         true,
+	ActionGetMeas:             true,
+	ActionGetSleep:            true, // also ActionGetNotification and ActionGetHeartSignal
+	ActionGetSleepSummary:     true,
+	ActionListNotifications:   true, // also ActionListHeart
+	ActionGetDevices:          true,
+}
+
+// maxGETURLLength is the longest URL sent as a GET request. Longer requests,
+// such as those carrying a callback URL with a long signed query, might be
+// rejected by servers and proxies on the way, so they are sent as POST
+// requests instead.
+const maxGETURLLength = 2048
+
+// isIdempotent reports whether the action in v can safely be retried.
+func isIdempotent(v url.Values) bool {
+	return idempotentActions[Action(v.Get("action"))]
+}
+
+// endpointAction identifies an API action. Action names alone are ambiguous,
+// e.g. "get" is both a sleep and a notification action.
+type endpointAction struct {
+	path   string
+	action Action
+}
+
+// requiredScopes lists the scope each data action needs. Actions that aren't
+// listed are not checked before they are sent.
+var requiredScopes = map[endpointAction]Scope{
+	{getIntradayActivitiesPath, ActionGetIntradayActivity}: ScopeUserActivity,
+	{getActivityMeasuresPath, ActionGetActivity}:           ScopeUserActivity,
+	{getWorkoutsPath, ActionGetWorkouts}:                   ScopeUserActivity,
+	{getBodyMeasurePath, ActionGetMeas}:                    ScopeUserMetrics,
+	{getSleepMeasurePath, ActionGetSleep}:                  ScopeUserActivity,
+	{getSleepSummaryPath, ActionGetSleepSummary}:           ScopeUserActivity,
+	{getDevicesPath, ActionGetDevices}:                     ScopeUserInfo,
+	{getHeartListPath, ActionListHeart}:                    ScopeUserMetrics,
+	{getHeartSignalPath, ActionGetHeartSignal}:             ScopeUserMetrics,
+}
+
+// checkScope returns a *ScopeError if the user is known not to have granted
+// the scope required by the action in v sent to endpointPath.
+func (u *User) checkScope(endpointPath string, v url.Values) error {
+	action := Action(v.Get("action"))
+	required, ok := requiredScopes[endpointAction{endpointPath, action}]
+	if !ok || u.HasScope(required) {
+		return nil
+	}
+	return &ScopeError{Action: action, Required: required, Granted: u.Scopes()}
+}
+
+// Limiter paces the requests sent to the API. Wait blocks until the next
+// request may be sent, or returns an error if ctx is done first. It is
+// satisfied by *rate.Limiter from golang.org/x/time/rate.
+type Limiter interface {
+	Wait(ctx context.Context) error
+}
+
+// RequestOption customizes individual API requests. Options are attached to
+// the context passed to the Ctx variant of a method with WithRequestOptions.
+type RequestOption func(*requestOptions)
+
+// requestOptions holds the combined effect of a set of RequestOptions.
+type requestOptions struct {
+	header      http.Header
+	noDataError bool
+	timeout     time.Duration
+	paging      bool
+
+	// retries, if set, overrides the client's retry policy.
+	retries *retryPolicy
+	// limiter replaces the client's Limiter if limiterSet.
+	limiter    Limiter
+	limiterSet bool
+}
+
+// retryPolicy is how often and how patiently rate limited requests are
+// retried, as per Client.MaxRetries and Client.BackoffBase.
+type retryPolicy struct {
+	max  int
+	base time.Duration
+	// pause is how long the user's requests are paused once a request gives
+	// up, if the response doesn't say how long to wait.
+	pause time.Duration
+}
+
+// requestOptionsKey is the context key of the RequestOptions attached by
+// WithRequestOptions.
+type requestOptionsKey struct{}
+
+// WithRequestOptions returns a copy of ctx carrying opts, which apply to every
+// API request made with the returned context in addition to any options
+// already carried by ctx.
+func WithRequestOptions(ctx context.Context, opts ...RequestOption) context.Context {
+	existing, _ := ctx.Value(requestOptionsKey{}).([]RequestOption)
+	combined := append(append([]RequestOption(nil), existing...), opts...)
+	return context.WithValue(ctx, requestOptionsKey{}, combined)
+}
+
+// requestOptionsFrom applies the RequestOptions carried by ctx.
+func requestOptionsFrom(ctx context.Context) requestOptions {
+	o := requestOptions{header: http.Header{}}
+	opts, _ := ctx.Value(requestOptionsKey{}).([]RequestOption)
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithHeader sets an additional header on the request, such as a tracing
+// header. It takes precedence over Client.ExtraHeaders. The Authorization
+// header is always set by the client and can't be overridden.
+func WithHeader(key, value string) RequestOption {
+	return func(o *requestOptions) {
+		o.header.Set(key, value)
+	}
+}
+
+// WithTimeout bounds each API request separately to d, including reading the
+// response, within any deadline of the context itself. Helpers that make
+// several requests, such as GetIntradayRange, apply it to each of them, so a
+// long export can allow a slow request plenty of time without a deadline on
+// the export as a whole.
+func WithTimeout(d time.Duration) RequestOption {
+	return func(o *requestOptions) {
+		o.timeout = d
+	}
+}
+
+// WithRetries retries rate limited requests up to max times, starting with a
+// backoff of base, in place of the client's MaxRetries and BackoffBase. A base
+// of zero keeps the client's BackoffBase. Interactive requests can fail fast
+// with WithRetries(0, 0), while background syncs retry patiently. As with the
+// client's policy, only requests that read data are retried.
+//
+// Backoffs between retries still pause all of the user's requests, as does the
+// Retry-After header of a response the request gives up on. But if that
+// response doesn't say how long to wait, the user's other requests aren't
+// paused for the minute they would be under the client's policy, so a request
+// failing fast doesn't hold up the next one.
+func WithRetries(max int, base time.Duration) RequestOption {
+	return func(o *requestOptions) {
+		o.retries = &retryPolicy{max: max, base: base}
+	}
+}
+
+// WithLimiter makes requests wait for l instead of the client's Limiter, or
+// not wait for any limiter if l is nil.
+func WithLimiter(l Limiter) RequestOption {
+	return func(o *requestOptions) {
+		o.limiter = l
+		o.limiterSet = true
+	}
+}
+
+// SkipLimiter makes requests not wait for the client's Limiter, e.g. for an
+// interactive request that shouldn't queue behind a batch of background ones.
+func SkipLimiter() RequestOption {
+	return WithLimiter(nil)
+}
+
+// NoDataAsError makes Get and List methods that succeed without returning any
+// data also return ErrNoData, so "nothing there" can be handled as an error
+// rather than by checking the NoData field of the response.
+func NoDataAsError() RequestOption {
+	return noDataAsError(true)
+}
+
+// noDataAsError sets whether ErrNoData is returned. Helpers that combine
+// several requests disable it for the individual requests, as an empty page
+// doesn't mean the combined result is empty.
+func noDataAsError(enabled bool) RequestOption {
+	return func(o *requestOptions) {
+		o.noDataError = enabled
+	}
+}
+
+// paging marks requests made by helpers that follow the API's continuation
+// themselves, so truncated pages aren't warned about.
+func paging() RequestOption {
+	return func(o *requestOptions) {
+		o.paging = true
+	}
+}
+
+// followOffsets pages through the API's continuation: fetch is called with
+// the offset to request, starting at offset, and reports whether the API has
+// more data and the offset it continues at, until there is no more. It fails
+// if the API reports more data without advancing the offset.
+func followOffsets(offset *int, fetch func(offset *int) (more bool, next int, err error)) error {
+	for {
+		more, next, err := fetch(offset)
+		if err != nil || !more {
+			return err
+		}
+		if offset != nil && next <= *offset {
+			return fmt.Errorf("api indicated more data but did not advance the offset past %d", *offset)
+		}
+		offset = &next
+	}
+}
+
+// truncationWarnings returns a warning that a response is truncated, unless it
+// isn't or the request was made while paging.
+func truncationWarnings(ctx context.Context, truncated bool, offset int) []string {
+	if !truncated || requestOptionsFrom(ctx).paging {
+		return nil
+	}
+	return []string{fmt.Sprintf("response is truncated; more data is available from offset %d", offset)}
+}
+
+// noData returns ErrNoData if the response is empty and the request was made
+// with NoDataAsError.
+func noData(ctx context.Context, empty bool) error {
+	if empty && requestOptionsFrom(ctx).noDataError {
+		return ErrNoData
+	}
+	return nil
+}
+
+// setExtraHeaders sets the headers on req, later ones taking precedence. The
+// Authorization header is skipped, as it's owned by the oauth2 transport.
+func setExtraHeaders(req *http.Request, headers ...http.Header) {
+	for _, h := range headers {
+		for k, vs := range h {
+			if http.CanonicalHeaderKey(k) == "Authorization" {
+				continue
+			}
+			req.Header[http.CanonicalHeaderKey(k)] = append([]string(nil), vs...)
+		}
+	}
+}
+
+// sendResult is the outcome of a request made by send.
+type sendResult struct {
+	// Path is the endpoint URL with the values of the action as its query,
+	// regardless of method.
+	Path string
+	// Body is the body of the response.
+	Body []byte
+	// FromCache reports whether the response was served from an HTTP cache
+	// rather than by the API, as marked by a caching transport with the
+	// X-From-Cache header.
+	FromCache bool
+	// RateLimit is the rate limit state reported by the response headers, if
+	// any.
+	RateLimit *RateLimit
+	// Warnings describe problems that didn't fail the request.
+	Warnings []string
+}
+
+// send sends the action described by v to the endpoint at path endpointPath
+// under the client's base URL. Idempotent actions are sent as GET requests
+// carrying v in the query, all others, and idempotent actions whose URL would
+// be longer than maxGETURLLength, as POST requests carrying v form-encoded in
+// the body. Requests whose URL would be longer than that are reported in the
+// Warnings of the result, which every endpoint passes on in its response's
+// Warnings. Failures to complete the request are returned as
+// a *NetworkError. Actions the user hasn't granted the scope for are not sent
+// at all and fail with a *ScopeError, and none are sent once the client has
+// been shut down. Requests wait for the client's Limiter, if any, or the one
+// set by WithLimiter, before each attempt is sent, and are then bounded by the
+// WithTimeout option. Once a request is rate limited, all of the user's
+// requests wait for the backoff the response asks for, and idempotent actions
+// are retried up to Client.MaxRetries times, or as set by WithRetries.
+// Responses are recorded or replayed as configured by RecordTo
+// and ReplayFrom. The Path of the result is set even if
+// sending fails.
+func (u *User) send(ctx context.Context, endpointPath string, v url.Values) (sendResult, error) {
+	return u.sendDecode(ctx, endpointPath, v, nil)
+}
+
+// sendDecode is as per send, but if dst isn't nil, a successful response is
+// also decoded into it. The body is then decoded as it is read rather than
+// held in memory, and the Body of the result is nil, unless it has to be kept
+// to be recorded or because SaveRawResponse is set.
+func (u *User) sendDecode(ctx context.Context, endpointPath string, v url.Values, dst Response) (res sendResult, err error) {
+	endpoint := u.Client.apiURL(endpointPath)
+	res.Path = fmt.Sprintf("%s?%s", endpoint, v.Encode())
+
+	if err := u.checkScope(endpointPath, v); err != nil {
+		return res, err
+	}
+
+	if err := u.Client.begin(); err != nil {
+		return res, err
+	}
+	defer u.Client.end()
+
+	if u.Client.replayDir != "" {
+		res.Body, err = u.Client.replay(endpointPath, v)
+		if err == nil && dst != nil {
+			err = decodeResponse(res.Body, dst)
+		}
+		return res, err
+	}
+
+	opts := requestOptionsFrom(ctx)
+	limiter := u.Client.Limiter
+	if opts.limiterSet {
+		limiter = opts.limiter
+	}
+
+	policy := retryPolicy{max: u.Client.MaxRetries, base: u.Client.BackoffBase, pause: defaultRateLimitBackoff}
+	if opts.retries != nil {
+		policy.max = opts.retries.max
+		if opts.retries.base > 0 {
+			policy.base = opts.retries.base
+		}
+		policy.pause = 0
+	}
+
+	// Requests that change state are always posted, but long ones are still
+	// reported, e.g. a subscription with a signed callback URL.
+	get := isIdempotent(v)
+	if len(res.Path) > maxGETURLLength {
+		get = false
+		res.Warnings = append(res.Warnings, fmt.Sprintf("request URL of %d bytes exceeds %d and was sent as POST", len(res.Path), maxGETURLLength))
+	}
+
+	var stream Response
+	if u.Client.recordDir == "" && !u.Client.SaveRawResponse {
+		stream = dst
+	}
+
+	var resp *http.Response
+	var body []byte
+	for attempt := 0; ; attempt++ {
+		if err := u.backoff.wait(ctx); err != nil {
+			return res, err
+		}
+		if limiter != nil {
+			if err := limiter.Wait(ctx); err != nil {
+				return res, err
+			}
+		}
+
+		resp, body, err = u.do(ctx, endpoint, res.Path, v, get, stream)
+		if err != nil {
+			return res, err
+		}
+
+		limited := rateLimited(resp, body)
+		if stream != nil && body == nil {
+			limited = stream.GetStatus() == status.TooManyRequets
+		}
+		if !limited {
+			break
+		}
+		retry := isIdempotent(v) && attempt < policy.max
+		u.backoff.pause(rateLimitBackoff(resp.Header, policy, attempt, retry))
+		if !retry {
+			break
+		}
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return res, &NetworkError{StatusCode: resp.StatusCode, Err: fmt.Errorf("%q", string(body))}
+	}
+
+	if u.Client.recordDir != "" {
+		if err := u.Client.record(endpointPath, v, body); err != nil {
+			return res, err
+		}
+	}
+
+	if dst != nil && stream == nil {
+		if err := decodeResponse(body, dst); err != nil {
+			return res, err
+		}
+	}
+
+	res.Body = body
+	res.FromCache = resp.Header.Get("X-From-Cache") == "1"
+	res.RateLimit = parseRateLimit(resp.Header, u.Client.now())
+	return res, nil
+}
+
+// do makes a single attempt at sending the action v to endpoint, as a GET
+// request to path if get is set or else as a POST request, and returns the
+// response along with its body, which has been read and closed. If dst isn't
+// nil, a successful response is instead decoded into it, replacing its
+// previous contents, and the returned body is nil. The attempt is bounded by
+// the WithTimeout option.
+func (u *User) do(ctx context.Context, endpoint, path string, v url.Values, get bool, dst Response) (*http.Response, []byte, error) {
+	if timeout := requestOptionsFrom(ctx).timeout; timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	var req *http.Request
+	var err error
+	if get {
+		req, err = http.NewRequestWithContext(ctx, "GET", path, nil)
+	} else {
+		req, err = http.NewRequestWithContext(ctx, "POST", endpoint, strings.NewReader(v.Encode()))
+		if err == nil {
+			req.Header.Set("content-type", "application/x-www-form-urlencoded")
+		}
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to build request: %s", err)
+	}
+	setExtraHeaders(req, u.Client.ExtraHeaders, requestOptionsFrom(ctx).header)
+
+	resp, err := u.HTTPClient.Do(req)
+	if err != nil {
+		return nil, nil, networkError(err)
+	}
+	defer resp.Body.Close()
+
+	if dst != nil && resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		reset := reflect.ValueOf(dst).Elem()
+		reset.Set(reflect.Zero(reset.Type()))
+		if err := json.NewDecoder(resp.Body).Decode(dst); err != nil {
+			return nil, nil, fmt.Errorf("decoding response: %w", err)
+		}
+		return resp, nil, nil
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, &NetworkError{StatusCode: resp.StatusCode, Err: err}
+	}
+	return resp, body, nil
+}
@@ -0,0 +1,299 @@
+package withings
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscribeIsNotRetried(t *testing.T) {
+	var calls int32
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		require.Equal(t, "POST", req.Method)
+		require.NoError(t, req.ParseForm())
+		require.Equal(t, "subscribe", req.PostForm.Get("action"))
+		require.Equal(t, "https://example.com/hook", req.PostForm.Get("callbackurl"))
+		rw.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	cb, err := url.Parse("https://example.com/hook")
+	require.NoError(t, err)
+
+	_, err = u.CreateNotification(&CreateNotificationParam{CallbackURL: *cb, Appli: 1})
+	require.Error(t, err)
+	require.EqualValues(t, 1, atomic.LoadInt32(&calls))
+}
+
+func TestReadActionsUseGet(t *testing.T) {
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		require.Equal(t, "GET", req.Method)
+		require.Equal(t, "getmeas", req.URL.Query().Get("action"))
+		fmt.Fprint(rw, `{"status":0,"body":{}}`)
+	})
+
+	_, err := u.GetBodyMeasures(nil)
+	require.NoError(t, err)
+}
+
+func TestFromCache(t *testing.T) {
+	cached := false
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		if cached {
+			rw.Header().Set("X-From-Cache", "1")
+		}
+		fmt.Fprint(rw, `{"status":0,"body":{}}`)
+	})
+
+	resp, err := u.GetBodyMeasures(nil)
+	require.NoError(t, err)
+	require.False(t, resp.FromCache)
+
+	cached = true
+	resp, err = u.GetBodyMeasures(nil)
+	require.NoError(t, err)
+	require.True(t, resp.FromCache)
+}
+
+func TestExtraHeaders(t *testing.T) {
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		require.Equal(t, "Bearer access-token", req.Header.Get("Authorization"))
+		require.Equal(t, "partner", req.Header.Get("X-Partner"))
+		require.Equal(t, "trace-1", req.Header.Get("X-Trace-Id"))
+		fmt.Fprint(rw, `{"status":0,"body":{}}`)
+	})
+	u.Client.ExtraHeaders = http.Header{
+		"X-Partner":     {"partner"},
+		"X-Trace-Id":    {"default"},
+		"Authorization": {"Bearer forged"},
+	}
+
+	ctx := WithRequestOptions(context.Background(),
+		WithHeader("X-Trace-Id", "trace-1"),
+		WithHeader("Authorization", "Bearer forged"),
+	)
+	_, err := u.GetBodyMeasuresCtx(ctx, nil)
+	require.NoError(t, err)
+}
+
+func TestWithTimeout(t *testing.T) {
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		select {
+		case <-time.After(200 * time.Millisecond):
+		case <-req.Context().Done():
+			return
+		}
+		fmt.Fprint(rw, `{"status":0,"body":{"series":[]}}`)
+	})
+
+	// Each request gets its own bound, independent of the client's Timeout.
+	u.Client.Timeout = time.Millisecond
+	ctx := WithRequestOptions(context.Background(), WithTimeout(5*time.Second))
+	_, err := u.GetSleepSummaryCtx(ctx, nil)
+	require.NoError(t, err)
+
+	ctx = WithRequestOptions(context.Background(), WithTimeout(20*time.Millisecond))
+	_, err = u.GetSleepSummaryCtx(ctx, nil)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestRateLimitBackoff(t *testing.T) {
+	var calls int32
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			rw.Header().Set("Retry-After", "1")
+			fmt.Fprint(rw, `{"status":601,"error":"Too Many Requests"}`)
+			return
+		}
+		fmt.Fprint(rw, `{"status":0,"body":{"series":[]}}`)
+	})
+
+	start := time.Now()
+	_, err := u.GetSleepSummaryCtx(context.Background(), nil)
+	require.Error(t, err)
+
+	// Requests made during the backoff wait for it, without reaching the API.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = u.GetSleepSummaryCtx(ctx, nil)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.EqualValues(t, 1, atomic.LoadInt32(&calls))
+
+	_, err = u.GetSleepSummaryCtx(context.Background(), nil)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, time.Since(start), time.Second)
+	require.EqualValues(t, 2, atomic.LoadInt32(&calls))
+}
+
+func TestRetryAfter(t *testing.T) {
+	d, ok := retryAfter("3")
+	require.True(t, ok)
+	require.Equal(t, 3*time.Second, d)
+
+	_, ok = retryAfter("")
+	require.False(t, ok)
+
+	d, ok = retryAfter(time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat))
+	require.True(t, ok)
+	require.Equal(t, time.Duration(0), d)
+
+	d, ok = retryAfter(time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+	require.True(t, ok)
+	require.InDelta(t, float64(time.Hour), float64(d), float64(2*time.Second))
+
+	client := retryPolicy{pause: defaultRateLimitBackoff}
+	require.Equal(t, defaultRateLimitBackoff, rateLimitBackoff(http.Header{}, client, 0, false))
+	require.Equal(t, time.Duration(0), rateLimitBackoff(http.Header{}, retryPolicy{}, 0, false))
+	require.Equal(t, 4*time.Second, rateLimitBackoff(http.Header{}, client, 2, true))
+	require.Equal(t, 20*time.Millisecond, rateLimitBackoff(http.Header{}, retryPolicy{base: 5 * time.Millisecond}, 2, true))
+	require.Equal(t, 5*time.Second, rateLimitBackoff(http.Header{"Retry-After": {"5"}}, client, 2, true))
+}
+
+func TestRetryRateLimited(t *testing.T) {
+	var calls int32
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		require.NoError(t, req.ParseForm())
+		n := atomic.AddInt32(&calls, 1)
+		switch {
+		case req.Form.Get("action") == "getsummary" && n <= 2:
+			fmt.Fprint(rw, `{"status":601,"error":"Too Many Requests"}`)
+		case req.Form.Get("action") == "getsummary":
+			fmt.Fprint(rw, `{"status":0,"body":{"series":[]}}`)
+		case req.Form.Get("action") == "getactivity":
+			fmt.Fprint(rw, `{"status":2554,"error":"Not implemented"}`)
+		default:
+			fmt.Fprint(rw, `{"status":601,"error":"Too Many Requests"}`)
+		}
+	})
+	u.Client.MaxRetries = 3
+	u.Client.BackoffBase = 10 * time.Millisecond
+
+	start := time.Now()
+	_, err := u.GetSleepSummary(nil)
+	require.NoError(t, err)
+	require.EqualValues(t, 3, atomic.LoadInt32(&calls))
+	require.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond)
+
+	// Other statuses fail immediately.
+	atomic.StoreInt32(&calls, 0)
+	_, err = u.GetActivityMeasures(nil)
+	require.Error(t, err)
+	require.EqualValues(t, 1, atomic.LoadInt32(&calls))
+
+	// Actions that change state are never retried.
+	atomic.StoreInt32(&calls, 0)
+	cb, err := url.Parse("https://example.com/hook")
+	require.NoError(t, err)
+	u.Client.BackoffBase = time.Millisecond
+	_, err = u.CreateNotificationCtx(context.Background(), &CreateNotificationParam{CallbackURL: *cb, Appli: 1})
+	require.Error(t, err)
+	require.EqualValues(t, 1, atomic.LoadInt32(&calls))
+}
+
+func TestRequestRetryOverrides(t *testing.T) {
+	var calls, limited int32
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if atomic.AddInt32(&limited, -1) >= 0 {
+			fmt.Fprint(rw, `{"status":601,"error":"Too Many Requests"}`)
+			return
+		}
+		fmt.Fprint(rw, `{"status":0,"body":{"series":[]}}`)
+	})
+	u.Client.MaxRetries = 3
+
+	// An interactive request fails fast despite the client's policy.
+	atomic.StoreInt32(&limited, 1)
+	ctx := WithRequestOptions(context.Background(), WithRetries(0, 0))
+	_, err := u.GetSleepSummaryCtx(ctx, nil)
+	require.Error(t, err)
+	require.EqualValues(t, 1, atomic.LoadInt32(&calls))
+
+	// Without a Retry-After header, failing fast doesn't pause the user's
+	// next request for the client's default backoff.
+	next, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, err = u.GetSleepSummaryCtx(next, nil)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, atomic.LoadInt32(&calls))
+
+	// A background request retries although the client doesn't.
+	u.Client.MaxRetries = 0
+	atomic.StoreInt32(&calls, 0)
+	atomic.StoreInt32(&limited, 2)
+	ctx = WithRequestOptions(context.Background(), WithRetries(2, time.Millisecond))
+	_, err = u.GetSleepSummaryCtx(ctx, nil)
+	require.NoError(t, err)
+	require.EqualValues(t, 3, atomic.LoadInt32(&calls))
+}
+
+func TestRequestLimiterOverrides(t *testing.T) {
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(rw, `{"status":0,"body":{"series":[]}}`)
+	})
+	clientLimiter := &countingLimiter{}
+	u.Client.Limiter = clientLimiter
+
+	_, err := u.GetSleepSummaryCtx(context.Background(), nil)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, atomic.LoadInt32(&clientLimiter.waits))
+
+	_, err = u.GetSleepSummaryCtx(WithRequestOptions(context.Background(), SkipLimiter()), nil)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, atomic.LoadInt32(&clientLimiter.waits))
+
+	requestLimiter := &countingLimiter{}
+	_, err = u.GetSleepSummaryCtx(WithRequestOptions(context.Background(), WithLimiter(requestLimiter)), nil)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, atomic.LoadInt32(&clientLimiter.waits))
+	require.EqualValues(t, 1, atomic.LoadInt32(&requestLimiter.waits))
+}
+
+func TestRetriesWaitForLimiter(t *testing.T) {
+	var calls int32
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			fmt.Fprint(rw, `{"status":601,"error":"Too Many Requests"}`)
+			return
+		}
+		fmt.Fprint(rw, `{"status":0,"body":{"series":[]}}`)
+	})
+	limiter := &countingLimiter{}
+	u.Client.Limiter = limiter
+
+	ctx := WithRequestOptions(context.Background(), WithRetries(1, time.Millisecond))
+	_, err := u.GetSleepSummaryCtx(ctx, nil)
+	require.NoError(t, err)
+	require.EqualValues(t, 2, atomic.LoadInt32(&calls))
+	require.EqualValues(t, 2, atomic.LoadInt32(&limiter.waits))
+}
+
+func TestRateLimitHeaders(t *testing.T) {
+	headers := http.Header{}
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		for k, v := range headers {
+			rw.Header()[k] = v
+		}
+		fmt.Fprint(rw, `{"status":0,"body":{"series":[]}}`)
+	})
+
+	r, err := u.GetSleepSummary(nil)
+	require.NoError(t, err)
+	require.Nil(t, r.RateLimit)
+
+	headers.Set("X-RateLimit-Remaining", "42")
+	headers.Set("X-RateLimit-Reset", "1700000000")
+	r, err = u.GetSleepSummary(nil)
+	require.NoError(t, err)
+	require.Equal(t, &RateLimit{Remaining: 42, Reset: time.Unix(1700000000, 0)}, r.RateLimit)
+
+	now := time.Now()
+	rl := parseRateLimit(http.Header{"Ratelimit-Remaining": {"0"}, "Ratelimit-Reset": {"30"}}, now)
+	require.Equal(t, &RateLimit{Remaining: 0, Reset: now.Add(30 * time.Second)}, rl)
+}
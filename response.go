@@ -0,0 +1,187 @@
+package withings
+
+import "github.com/asymmetricia/withings/enum/status"
+
+// Response is implemented by every response type, so code such as loggers and
+// tests can inspect the outcome of any request uniformly.
+type Response interface {
+	// GetStatus returns the status reported in the response body.
+	GetStatus() status.Status
+	// GetError returns the error message reported in the response body, if
+	// any.
+	GetError() string
+}
+
+var (
+	_ Response = RevokeNotificationResp{}
+	_ Response = NotificationInfoResp{}
+	_ Response = ListNotificationsResp{}
+	_ Response = CreateNotificationResp{}
+	_ Response = SleepSummaryResp{}
+	_ Response = SleepMeasuresResp{}
+	_ Response = IntradayActivityResp{}
+	_ Response = WorkoutResponse{}
+	_ Response = ActivitiesMeasuresResp{}
+	_ Response = BodyMeasuresResp{}
+	_ Response = GetDevicesResp{}
+	_ Response = HeartListResp{}
+	_ Response = HeartSignalResp{}
+)
+
+// GetStatus implements Response.
+func (r RevokeNotificationResp) GetStatus() status.Status {
+	return r.Status
+}
+
+// GetError implements Response.
+func (r RevokeNotificationResp) GetError() string {
+	return r.Error
+}
+
+// GetStatus implements Response.
+func (r NotificationInfoResp) GetStatus() status.Status {
+	return r.Status
+}
+
+// GetError implements Response.
+func (r NotificationInfoResp) GetError() string {
+	return r.Error
+}
+
+// GetStatus implements Response.
+func (r ListNotificationsResp) GetStatus() status.Status {
+	return r.Status
+}
+
+// GetError implements Response.
+func (r ListNotificationsResp) GetError() string {
+	return r.Error
+}
+
+// GetStatus implements Response.
+func (r CreateNotificationResp) GetStatus() status.Status {
+	return r.Status
+}
+
+// GetError implements Response.
+func (r CreateNotificationResp) GetError() string {
+	return r.Error
+}
+
+// GetStatus implements Response.
+func (r SleepSummaryResp) GetStatus() status.Status {
+	return r.Status
+}
+
+// GetError implements Response.
+func (r SleepSummaryResp) GetError() string {
+	return r.Error
+}
+
+// GetStatus implements Response.
+func (r SleepMeasuresResp) GetStatus() status.Status {
+	return r.Status
+}
+
+// GetError implements Response.
+func (r SleepMeasuresResp) GetError() string {
+	return r.Error
+}
+
+// GetStatus implements Response.
+func (r IntradayActivityResp) GetStatus() status.Status {
+	return r.Status
+}
+
+// GetError implements Response.
+func (r IntradayActivityResp) GetError() string {
+	return r.Error
+}
+
+// GetStatus implements Response.
+func (r WorkoutResponse) GetStatus() status.Status {
+	return r.Status
+}
+
+// GetError implements Response.
+func (r WorkoutResponse) GetError() string {
+	return r.Error
+}
+
+// GetStatus implements Response.
+func (r ActivitiesMeasuresResp) GetStatus() status.Status {
+	return r.Status
+}
+
+// GetError implements Response.
+func (r ActivitiesMeasuresResp) GetError() string {
+	return r.Error
+}
+
+// GetStatus implements Response.
+func (r BodyMeasuresResp) GetStatus() status.Status {
+	return r.Status
+}
+
+// GetError implements Response.
+func (r BodyMeasuresResp) GetError() string {
+	return r.Error
+}
+
+// GetStatus implements Response.
+func (r GetDevicesResp) GetStatus() status.Status {
+	return r.Status
+}
+
+// GetError implements Response.
+func (r GetDevicesResp) GetError() string {
+	return r.Error
+}
+
+// GetStatus implements Response.
+func (r HeartListResp) GetStatus() status.Status {
+	return r.Status
+}
+
+// GetError implements Response.
+func (r HeartListResp) GetError() string {
+	return r.Error
+}
+
+// GetStatus implements Response.
+func (r HeartSignalResp) GetStatus() status.Status {
+	return r.Status
+}
+
+// GetError implements Response.
+func (r HeartSignalResp) GetError() string {
+	return r.Error
+}
+
+// Truncated reports whether the API has more measure groups than it returned,
+// which GetAllBodyMeasuresCtx would follow.
+func (r BodyMeasuresResp) Truncated() bool {
+	return r.Body != nil && r.Body.More != 0
+}
+
+// Truncated reports whether the API has more activities than it returned.
+func (r ActivitiesMeasuresResp) Truncated() bool {
+	return r.Body != nil && r.Body.More
+}
+
+// Truncated reports whether the API has more workouts than it returned.
+func (r WorkoutResponse) Truncated() bool {
+	return r.Body != nil && r.Body.More
+}
+
+// Truncated reports whether the API has more sleep summaries than it
+// returned, which GetAllSleepSummaryCtx would follow.
+func (r SleepSummaryResp) Truncated() bool {
+	return r.Body != nil && r.Body.More
+}
+
+// Truncated reports whether the API has more heart recordings than it
+// returned.
+func (r HeartListResp) Truncated() bool {
+	return r.Body != nil && r.Body.More
+}
@@ -0,0 +1,72 @@
+package withings
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrClientShutdown is returned for requests started after Client.Shutdown.
+var ErrClientShutdown = errors.New("client is shut down")
+
+// Shutdown stops the client from starting new API requests, which fail with
+// ErrClientShutdown, and waits for the requests already in flight to finish.
+// If ctx is done first, its error is returned and the remaining requests are
+// left to complete on their own. Shutdown is meant to be called alongside
+// http.Server.Shutdown when a server handling many users stops.
+func (c *Client) Shutdown(ctx context.Context) error {
+	s := c.state
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	s.shutdown = true
+	if s.idle == nil {
+		s.idle = make(chan struct{})
+		if s.inFlight == 0 {
+			close(s.idle)
+		}
+	}
+	idle := s.idle
+	s.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// begin records the start of a request, or returns ErrClientShutdown if the
+// client has been shut down. Every successful call must be paired with a call
+// to end.
+func (c *Client) begin() error {
+	s := c.state
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.shutdown {
+		return ErrClientShutdown
+	}
+	s.inFlight++
+	return nil
+}
+
+// end records the completion of a request started with begin.
+func (c *Client) end() {
+	s := c.state
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.inFlight--
+	if s.shutdown && s.inFlight == 0 {
+		close(s.idle)
+	}
+}
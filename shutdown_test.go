@@ -0,0 +1,41 @@
+package withings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestShutdownDrainsInFlight(t *testing.T) {
+	entered := make(chan struct{}, 1)
+	release := make(chan struct{})
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		entered <- struct{}{}
+		<-release
+		fmt.Fprint(rw, `{"status":0,"body":{}}`)
+	})
+	u.Client.Timeout = time.Minute
+
+	done := make(chan error)
+	go func() {
+		_, err := u.GetBodyMeasures(nil)
+		done <- err
+	}()
+	<-entered
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, u.Client.Shutdown(ctx), context.DeadlineExceeded)
+
+	_, err := u.GetBodyMeasures(nil)
+	require.True(t, errors.Is(err, ErrClientShutdown))
+
+	close(release)
+	require.NoError(t, <-done)
+	require.NoError(t, u.Client.Shutdown(context.Background()))
+}
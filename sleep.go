@@ -0,0 +1,50 @@
+package withings
+
+// MergeDedup returns the sleep summaries of rs followed by those of other,
+// with each night only included once, so overlapping sync windows can be
+// combined without double counting. Nights are identified by their ID, or by
+// their date if the ID is missing. Where both responses hold the same night,
+// the entry with the later Modified time is kept, in the position of its first
+// appearance. The other fields of the result are taken from rs.
+func (rs SleepSummaryResp) MergeDedup(other SleepSummaryResp) SleepSummaryResp {
+	type night struct {
+		id   int64
+		date string
+	}
+	key := func(s SleepSummary) night {
+		if s.ID != 0 {
+			return night{id: s.ID}
+		}
+		return night{date: s.Date}
+	}
+
+	var series []SleepSummary
+	index := map[night]int{}
+	for _, r := range []SleepSummaryResp{rs, other} {
+		if r.Body == nil {
+			continue
+		}
+		for _, s := range r.Body.Series {
+			k := key(s)
+			i, ok := index[k]
+			if !ok {
+				index[k] = len(series)
+				series = append(series, s)
+				continue
+			}
+			if s.Modified > series[i].Modified {
+				series[i] = s
+			}
+		}
+	}
+
+	merged := rs
+	body := SleepSummaryBody{}
+	if rs.Body != nil {
+		body = *rs.Body
+	}
+	body.Series = series
+	merged.Body = &body
+	merged.NoData = len(series) == 0
+	return merged
+}
@@ -0,0 +1,174 @@
+package withings
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/asymmetricia/withings/enum/sleepsummaryfield"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetAllSleepSummaryFollowsOffset(t *testing.T) {
+	var offsets []string
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		offsets = append(offsets, req.URL.Query().Get("offset"))
+		require.Equal(t, "2021-01-01", req.URL.Query().Get("startdateymd"))
+
+		switch req.URL.Query().Get("offset") {
+		case "":
+			fmt.Fprint(rw, `{"status":0,"body":{"more":true,"offset":1,"series":[
+				{"id":1,"startdate":1609545600,"enddate":1609574400,"date":"2021-01-02","timezone":"Europe/Paris"}
+			]}}`)
+		case "1":
+			fmt.Fprint(rw, `{"status":0,"body":{"more":false,"offset":0,"series":[
+				{"id":2,"startdate":1609632000,"enddate":1609660800,"date":"2021-01-03","timezone":"Europe/Paris"}
+			]}}`)
+		default:
+			t.Errorf("unexpected offset %q", req.URL.Query().Get("offset"))
+		}
+	})
+
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(1, 0, 0)
+	resp, err := u.GetAllSleepSummaryCtx(context.Background(), &SleepSummaryQueryParam{
+		StartDateYMD: &start,
+		EndDateYMD:   &end,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"", "1"}, offsets)
+
+	require.Len(t, resp.Body.Series, 2)
+	require.EqualValues(t, 1, resp.Body.Series[0].ID)
+	require.EqualValues(t, 2, resp.Body.Series[1].ID)
+	for _, s := range resp.Body.Series {
+		require.NotNil(t, s.StartDateParsed)
+		require.NotNil(t, s.DateParsed)
+	}
+}
+
+func TestGetAllSleepSummaryStalledOffset(t *testing.T) {
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(rw, `{"status":0,"body":{"more":true,"offset":0,"series":[]}}`)
+	})
+
+	_, err := u.GetAllSleepSummaryCtx(context.Background(), nil)
+	require.Error(t, err)
+}
+
+func TestSleepSummaryMergeDedup(t *testing.T) {
+	a := SleepSummaryResp{Body: &SleepSummaryBody{Series: []SleepSummary{
+		{ID: 1, Date: "2021-01-02", Modified: 100},
+		{ID: 2, Date: "2021-01-03", Modified: 200},
+		{Date: "2021-01-04", Modified: 300},
+	}}}
+	b := SleepSummaryResp{Body: &SleepSummaryBody{Series: []SleepSummary{
+		{ID: 2, Date: "2021-01-03", Modified: 250},
+		{ID: 1, Date: "2021-01-02", Modified: 50},
+		{Date: "2021-01-04", Modified: 100},
+		{ID: 3, Date: "2021-01-05", Modified: 400},
+	}}}
+
+	merged := a.MergeDedup(b)
+	require.Len(t, merged.Body.Series, 4)
+	require.EqualValues(t, 100, merged.Body.Series[0].Modified)
+	require.EqualValues(t, 250, merged.Body.Series[1].Modified)
+	require.EqualValues(t, 300, merged.Body.Series[2].Modified)
+	require.EqualValues(t, 3, merged.Body.Series[3].ID)
+	require.Len(t, a.Body.Series, 3)
+
+	empty := SleepSummaryResp{}.MergeDedup(SleepSummaryResp{})
+	require.NotNil(t, empty.Body)
+	require.True(t, empty.NoData)
+}
+
+func TestSleepAnalyzerModel(t *testing.T) {
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		switch req.URL.Query().Get("action") {
+		case "get":
+			fmt.Fprint(rw, `{"status":0,"body":{"model":32,"series":[
+				{"startdate":1609545600,"enddate":1609546200,"state":1,"model_id":63},
+				{"startdate":1609546200,"enddate":1609547400,"state":2,"model_id":63}
+			]}}`)
+		case "getsummary":
+			fmt.Fprint(rw, `{"status":0,"body":{"series":[
+				{"id":1,"startdate":1609545600,"enddate":1609574400,"date":"2021-01-02","model":32,"model_id":63}
+			]}}`)
+		}
+	})
+
+	measures, err := u.GetSleepMeasures(nil)
+	require.NoError(t, err)
+	require.Equal(t, SleepModelSleepAnalyzer, measures.Body.Model)
+	require.Len(t, measures.Body.Series, 2)
+	require.Equal(t, 63, measures.Body.Series[0].ModelID)
+
+	summary, err := u.GetSleepSummary(nil)
+	require.NoError(t, err)
+	require.Equal(t, SleepModelSleepAnalyzer, summary.Body.Series[0].Model)
+	require.Equal(t, 63, summary.Body.Series[0].ModelID)
+}
+
+func TestSleepMeasuresLastUpdate(t *testing.T) {
+	lastUpdate := time.Unix(1636300800, 0)
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		require.Equal(t, "get", req.URL.Query().Get("action"))
+		require.Equal(t, "1636300800", req.URL.Query().Get("lastupdate"))
+		require.NotEmpty(t, req.URL.Query().Get("startdate"))
+		require.NotEmpty(t, req.URL.Query().Get("enddate"))
+		fmt.Fprint(rw, `{"status":0,"body":{"series":[]}}`)
+	})
+
+	_, err := u.GetSleepMeasures(&SleepMeasuresQueryParam{
+		StartDate:  lastUpdate.AddDate(0, 0, -1),
+		EndDate:    lastUpdate,
+		LastUpdate: &lastUpdate,
+	})
+	require.NoError(t, err)
+}
+
+func TestGetAllSleepSummaryCancelledReturnsPartial(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Query().Get("offset") == "" {
+			fmt.Fprint(rw, `{"status":0,"body":{"more":true,"offset":1,"series":[{"id":1,"date":"2021-03-15"}]}}`)
+			return
+		}
+		cancel()
+		<-req.Context().Done()
+	})
+
+	resp, err := u.GetAllSleepSummaryCtx(ctx, nil)
+	require.ErrorIs(t, err, context.Canceled)
+	require.Len(t, resp.Body.Series, 1)
+	require.True(t, resp.Truncated())
+}
+
+func TestSleepSummaryDataFields(t *testing.T) {
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		require.Equal(t, "snoring,breathing_disturbances_intensity", req.URL.Query().Get("data_fields"))
+		fmt.Fprint(rw, `{"status":0,"body":{"series":[{"id":1,"date":"2021-01-01","data":{"snoring":900,"breathing_disturbances_intensity":12}}]}}`)
+	})
+
+	day := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	r, err := u.GetSleepSummary(&SleepSummaryQueryParam{
+		StartDateYMD: &day,
+		EndDateYMD:   &day,
+		DataFields:   []sleepsummaryfield.Field{sleepsummaryfield.Snoring, sleepsummaryfield.BreathingDisturbancesIntensity},
+	})
+	require.NoError(t, err)
+	require.Len(t, r.Body.Series, 1)
+
+	s := r.Body.Series[0]
+	require.Equal(t, 15*time.Minute, s.SnoringDuration())
+	require.NotNil(t, s.Data.BreathingDisturbancesIntensity)
+	require.Equal(t, 12, *s.Data.BreathingDisturbancesIntensity)
+	require.Nil(t, s.Data.ApneaHypopneaIndex)
+
+	_, err = u.GetSleepSummary(&SleepSummaryQueryParam{DataFields: []sleepsummaryfield.Field{"bogus"}})
+	require.Error(t, err)
+}
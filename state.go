@@ -0,0 +1,120 @@
+package withings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultStateTTL is how long the state of an authorization URL stays valid if
+// Client.StateTTL is zero.
+const DefaultStateTTL = 10 * time.Minute
+
+// StateStore keeps the states of the authorization URLs generated by
+// AuthCodeURL until they come back with the redirect. Deployments where the
+// redirect may reach a different instance than the one that generated the URL
+// should use a store shared between instances.
+type StateStore interface {
+	// Save records state as valid for ttl.
+	Save(state string, ttl time.Duration) error
+	// Consume reports whether state is recorded and hasn't expired, and
+	// removes it so it can't be used again.
+	Consume(state string) bool
+}
+
+// ErrInvalidState is returned by NewUserFromRedirect when the state of the
+// redirect is unknown, has expired or has already been used.
+var ErrInvalidState = errors.New("unknown, expired or reused authorization state")
+
+// MemoryStateStore is a StateStore that keeps states in memory. It is the
+// default store of clients created by NewClient, and is only suitable when
+// the redirect is handled by the process that generated the URL.
+type MemoryStateStore struct {
+	mu     sync.Mutex
+	states map[string]time.Time
+	now    func() time.Time
+}
+
+// NewMemoryStateStore returns an empty MemoryStateStore.
+func NewMemoryStateStore() *MemoryStateStore {
+	return &MemoryStateStore{states: map[string]time.Time{}, now: time.Now}
+}
+
+// Save implements StateStore. Expired states are discarded as new ones are
+// saved.
+func (s *MemoryStateStore) Save(state string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	for st, expiry := range s.states {
+		if !expiry.After(now) {
+			delete(s.states, st)
+		}
+	}
+	s.states[state] = now.Add(ttl)
+	return nil
+}
+
+// Consume implements StateStore.
+func (s *MemoryStateStore) Consume(state string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expiry, ok := s.states[state]
+	delete(s.states, state)
+	return ok && expiry.After(s.now())
+}
+
+// saveState records state in the client's StateStore, if it has one.
+func (c *Client) saveState(state string) error {
+	if c.StateStore == nil {
+		return nil
+	}
+	ttl := c.StateTTL
+	if ttl <= 0 {
+		ttl = DefaultStateTTL
+	}
+	if err := c.StateStore.Save(state, ttl); err != nil {
+		return fmt.Errorf("saving state: %w", err)
+	}
+	return nil
+}
+
+// NewUserFromRedirect generates a new user from the redirect request that
+// follows the authorization page, after checking its state against the
+// client's StateStore. Each state is only accepted once.
+func (c *Client) NewUserFromRedirect(ctx context.Context, req *http.Request) (*User, error) {
+	if c.StateStore == nil {
+		return nil, errors.New("handling redirect: client has no StateStore")
+	}
+	if !c.StateStore.Consume(req.FormValue("state")) {
+		return nil, fmt.Errorf("handling redirect: %w", ErrInvalidState)
+	}
+	if e := req.FormValue("error"); e != "" {
+		return nil, fmt.Errorf("handling redirect: authorization denied: %s", e)
+	}
+	code := req.FormValue("code")
+	if code == "" {
+		return nil, errors.New("handling redirect: no code")
+	}
+
+	u, err := c.NewUserFromAuthCode(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("handling redirect: %w", err)
+	}
+	return u, nil
+}
+
+// CallbackHandler returns a handler for the redirect that follows the
+// authorization page. It generates the user as per NewUserFromRedirect and
+// passes the outcome to done, which must write the response.
+func (c *Client) CallbackHandler(done func(rw http.ResponseWriter, req *http.Request, u *User, err error)) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		u, err := c.NewUserFromRedirect(req.Context(), req)
+		done(rw, req, u, err)
+	})
+}
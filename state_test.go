@@ -0,0 +1,68 @@
+package withings
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStateStore(t *testing.T) {
+	now := time.Now()
+	s := NewMemoryStateStore()
+	s.now = func() time.Time { return now }
+
+	require.NoError(t, s.Save("a", time.Minute))
+	require.NoError(t, s.Save("b", time.Minute))
+	require.False(t, s.Consume("c"))
+	require.True(t, s.Consume("a"))
+	require.False(t, s.Consume("a"), "states are single use")
+
+	now = now.Add(2 * time.Minute)
+	require.False(t, s.Consume("b"), "expired states are rejected")
+
+	require.NoError(t, s.Save("c", time.Minute))
+	require.Len(t, s.states, 1)
+}
+
+func TestCallbackHandlerSharedStateStore(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(rw, `{"status":0,"body":{"userid":1234,"access_token":"access","refresh_token":"refresh","expires_in":10800,"token_type":"Bearer"}}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	// The URL is generated by one instance and the redirect handled by
+	// another, sharing a store.
+	store := NewMemoryStateStore()
+	initiator := NewClient("client-id", "client-secret", "http://localhost:8888")
+	initiator.StateStore = store
+	receiver := NewClient("client-id", "client-secret", "http://localhost:8888")
+	receiver.StateStore = store
+	receiver.BaseURL = srv.URL
+
+	raw, state, err := initiator.AuthCodeURL()
+	require.NoError(t, err)
+	authURL, err := url.Parse(raw)
+	require.NoError(t, err)
+	require.Equal(t, state, authURL.Query().Get("state"))
+
+	var user *User
+	var handlerErr error
+	h := receiver.CallbackHandler(func(rw http.ResponseWriter, req *http.Request, u *User, err error) {
+		user, handlerErr = u, err
+	})
+
+	redirect := "/?" + url.Values{"code": {"code"}, "state": {state}}.Encode()
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", redirect, nil))
+	require.NoError(t, handlerErr)
+	require.Equal(t, "access", user.OauthToken.AccessToken)
+
+	// Replaying the redirect fails.
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", redirect, nil))
+	require.ErrorIs(t, handlerErr, ErrInvalidState)
+	require.Nil(t, user)
+}
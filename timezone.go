@@ -0,0 +1,108 @@
+package withings
+
+import (
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// locations caches the locations loaded by time.LoadLocation by name. A large
+// response typically repeats the same few timezones on every record, and
+// loading one reads and parses its zoneinfo each time.
+var locations sync.Map
+
+// loadLocation is time.LoadLocation, memoized in locations. Only locations
+// that load are cached, so the cache is bounded by the set of valid zone names
+// and offsets however many bad names responses carry. Withings occasionally
+// sends a UTC offset rather than an IANA name, which is resolved to a fixed
+// zone; see offsetZone.
+func loadLocation(name string) (*time.Location, error) {
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		fixed, ok := offsetZone(name)
+		if !ok {
+			return nil, err
+		}
+		loc = fixed
+	}
+	locations.Store(name, loc)
+	return loc, nil
+}
+
+// offsetPattern matches a signed UTC offset in hours and optional minutes,
+// such as "+0200", "-05:30" or "+02".
+var offsetPattern = regexp.MustCompile(`^([+-])(\d{2}):?(\d{2})?$`)
+
+// maxOffset bounds the offsets accepted by offsetZone. Offsets given in
+// seconds must also be a multiple of offsetGranularity, as every real UTC
+// offset is, so that small numbers aren't mistaken for offsets.
+const (
+	maxOffset         = 18 * 60 * 60
+	offsetGranularity = 15 * 60
+)
+
+// offsetZone returns a fixed zone for a timezone given as a UTC offset, either
+// signed hours and minutes such as "+0200" or a number of seconds such as
+// "7200", and whether name is such an offset.
+func offsetZone(name string) (*time.Location, bool) {
+	var seconds int
+	if m := offsetPattern.FindStringSubmatch(name); m != nil {
+		hours, _ := strconv.Atoi(m[2])
+		minutes := 0
+		if m[3] != "" {
+			minutes, _ = strconv.Atoi(m[3])
+		}
+		if minutes >= 60 {
+			return nil, false
+		}
+		seconds = hours*60*60 + minutes*60
+		if m[1] == "-" {
+			seconds = -seconds
+		}
+	} else if n, err := strconv.Atoi(name); err == nil && n%offsetGranularity == 0 {
+		seconds = n
+	} else {
+		return nil, false
+	}
+
+	if seconds < -maxOffset || seconds > maxOffset {
+		return nil, false
+	}
+	return time.FixedZone(name, seconds), true
+}
+
+// location returns the location named by the timezone of a record. An empty or
+// unrecognized name resolves to DefaultTimezone, or UTC if that is unset, so a
+// single bad record doesn't fail a whole response.
+func (c *Client) location(name string) *time.Location {
+	if name != "" {
+		if loc, err := loadLocation(name); err == nil {
+			return loc
+		}
+	}
+
+	if c.DefaultTimezone != nil {
+		return c.DefaultTimezone
+	}
+	return time.UTC
+}
+
+// latestZone is the timezone furthest ahead of UTC, in which the current day
+// starts first.
+var latestZone = time.FixedZone("UTC+14", 14*60*60)
+
+// today returns the current time in DefaultTimezone, or, if that is unset, in
+// the timezone furthest ahead of UTC. Its date is therefore never before the
+// current date of the user, so a range of days ending with it includes the
+// user's readings from earlier today whatever their timezone.
+func (c *Client) today() time.Time {
+	if c.DefaultTimezone != nil {
+		return c.now().In(c.DefaultTimezone)
+	}
+	return c.now().In(latestZone)
+}
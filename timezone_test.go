@@ -0,0 +1,62 @@
+package withings
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocationFallback(t *testing.T) {
+	c := NewClient("id", "secret", "http://localhost:8888")
+	require.Equal(t, "Europe/Paris", c.location("Europe/Paris").String())
+	require.Equal(t, time.UTC, c.location(""))
+	require.Equal(t, time.UTC, c.location("Not/AZone"))
+
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	require.NoError(t, err)
+	c.DefaultTimezone = tokyo
+	require.Equal(t, tokyo, c.location(""))
+	require.Equal(t, tokyo, c.location("Not/AZone"))
+}
+
+func TestLoadLocationCached(t *testing.T) {
+	first, err := loadLocation("America/Chicago")
+	require.NoError(t, err)
+	second, err := loadLocation("America/Chicago")
+	require.NoError(t, err)
+	require.Same(t, first, second)
+
+	_, err = loadLocation("Not/AZone")
+	require.Error(t, err)
+	_, cached := locations.Load("Not/AZone")
+	require.False(t, cached)
+}
+
+func TestOffsetTimezones(t *testing.T) {
+	c := NewClient("id", "secret", "http://localhost:8888")
+	at := time.Date(2021, 7, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := map[string]int{
+		"Europe/Paris": 2 * 60 * 60,
+		"+0200":        2 * 60 * 60,
+		"-05:30":       -(5*60*60 + 30*60),
+		"+09":          9 * 60 * 60,
+		"7200":         2 * 60 * 60,
+		"-18000":       -5 * 60 * 60,
+	}
+	for name, want := range tests {
+		_, offset := at.In(c.location(name)).Zone()
+		require.Equal(t, want, offset, name)
+	}
+
+	for _, name := range []string{"+0275", "999999", "+2"} {
+		require.Equal(t, time.UTC, c.location(name), name)
+	}
+
+	var resp BodyMeasuresResp
+	resp.Body = &BodyMeasureRespBody{MeasureGrps: []MeasureGroup{
+		{GrpID: 1, Date: time.Date(2021, 7, 1, 23, 0, 0, 0, time.UTC).Unix(), Timezone: "+0200"},
+	}}
+	require.Contains(t, resp.GroupByDay(), "2021-07-02")
+}
@@ -1,16 +1,22 @@
 package withings
 
 import (
+	"encoding/json"
+	"fmt"
 	"math"
 	"net/url"
 	"reflect"
+	"strings"
 	"time"
 
+	"github.com/asymmetricia/withings/enum/intradayfield"
 	"github.com/asymmetricia/withings/enum/meastype"
 	"github.com/asymmetricia/withings/enum/sleepstate"
+	"github.com/asymmetricia/withings/enum/sleepsummaryfield"
 
 	"github.com/asymmetricia/withings/enum/devtype"
 	"github.com/asymmetricia/withings/enum/status"
+	"github.com/asymmetricia/withings/enum/workoutfield"
 
 	"github.com/asymmetricia/withings/enum/workouttype"
 )
@@ -39,7 +45,96 @@ type RevokeNotificationResp struct {
 	Status      status.Status `json:"status"`
 	RawResponse []byte
 	Path        string
+	FromCache   bool
+	RateLimit   *RateLimit
 	Error       string
+	// Warnings describe problems that didn't fail the request, such as a
+	// callback URL too long to be sent in the query.
+	Warnings []string
+}
+
+// GetDevicesResp is the response from listing the user's devices.
+type GetDevicesResp struct {
+	Status      status.Status       `json:"status"`
+	Body        *GetDevicesRespBody `json:"body"`
+	RawResponse []byte
+	Path        string
+	FromCache   bool
+	RateLimit   *RateLimit
+	NoData      bool
+	Error       string
+	// Warnings describe problems that didn't fail the request, such as a
+	// request URL too long to be sent in the query.
+	Warnings []string
+}
+
+// GetDevicesRespBody represents the device list body.
+type GetDevicesRespBody struct {
+	Devices []Device `json:"devices"`
+}
+
+// Device is a device linked to the user's account.
+type Device struct {
+	// Type is the kind of device, or nil if it isn't known. Withings may
+	// describe it by name rather than by number, in which case TypeName holds
+	// the name and Type is only set if the name is one of the devtype
+	// constants.
+	Type     *devtype.DevType `json:"type"`
+	TypeName string           `json:"typename"`
+	Model    string           `json:"model"`
+	ModelID  int              `json:"model_id"`
+	// Battery is the battery level as reported by the API, e.g. "high",
+	// "medium" or "low".
+	Battery               string     `json:"battery"`
+	DeviceID              string     `json:"deviceid"`
+	HashDeviceID          string     `json:"hash_deviceid"`
+	Timezone              string     `json:"timezone"`
+	LastSessionDate       int64      `json:"last_session_date"`
+	LastSessionDateParsed *time.Time `json:"lastsessiondateparsed"`
+}
+
+// deviceTypeNames maps the names the API may use for device types to their
+// devtype constants.
+var deviceTypeNames = map[string]devtype.DevType{
+	"user related":           devtype.UserRelated,
+	"scale":                  devtype.BodyScale,
+	"blood pressure monitor": devtype.BloodPressureMonitor,
+	"activity tracker":       devtype.ActivityTracker,
+	"sleep monitor":          devtype.SleepMonitor,
+}
+
+// UnmarshalJSON decodes the device, accepting a type given as a number or as
+// a name, and a last session date sent as a string.
+func (d *Device) UnmarshalJSON(data []byte) error {
+	type plain Device
+	raw := struct {
+		*plain
+		Type            json.RawMessage `json:"type"`
+		LastSessionDate flexInt         `json:"last_session_date"`
+	}{plain: (*plain)(d), LastSessionDate: flexInt(d.LastSessionDate)}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	d.LastSessionDate = int64(raw.LastSessionDate)
+	d.Type = nil
+
+	if len(raw.Type) == 0 || string(raw.Type) == "null" {
+		return nil
+	}
+	var name string
+	if err := json.Unmarshal(raw.Type, &name); err == nil {
+		d.TypeName = name
+		if t, ok := deviceTypeNames[strings.ToLower(name)]; ok {
+			d.Type = &t
+		}
+		return nil
+	}
+	var t devtype.DevType
+	if err := json.Unmarshal(raw.Type, &t); err != nil {
+		return fmt.Errorf("decoding device type %s: %w", raw.Type, err)
+	}
+	d.Type = &t
+	return nil
 }
 
 // NotificationInfoParam provides the query parameters nessasary to retrieve
@@ -56,19 +151,48 @@ type NotificationInfoResp struct {
 	Body        *NotificationInfoRespBody `json:"body"`
 	RawResponse []byte
 	Path        string
+	FromCache   bool
+	RateLimit   *RateLimit
 	Error       string
+	// Warnings describe problems that didn't fail the request, such as a
+	// callback URL too long to be sent in the query.
+	Warnings []string
 }
 
 // NotificationInfoRespBody represents the body of the notification response.
+// The API doesn't report delivery failures. Once Withings revokes a
+// subscription, e.g. after repeated failed deliveries, GetNotificationInformation
+// fails with an *APIError instead, and the expiry tells how long an active
+// subscription remains valid.
 type NotificationInfoRespBody struct {
+	Appli         int        `json:"appli"`
+	CallbackURL   string     `json:"callbackurl"`
 	Expires       int64      `json:"expires"`
 	Comment       string     `json:"comment"`
 	ExpiresParsed *time.Time `json:"expiresparsed"`
+
+	// now is the clock of the client that retrieved the body, if any.
+	now func() time.Time
+}
+
+// ExpiresWithin reports whether the subscription expires within d from now,
+// so it can be renewed before Withings stops delivering notifications. Now is
+// according to the API's clock, as per Client.ClockSkew, if the body was
+// retrieved by GetNotificationInformationCtx.
+func (b NotificationInfoRespBody) ExpiresWithin(d time.Duration) bool {
+	now := time.Now
+	if b.now != nil {
+		now = b.now
+	}
+	return time.Unix(b.Expires, 0).Before(now().Add(d))
 }
 
 // ListNotificationsParam provides the query parameters nessasary to list
 // all the notifications configured for the user.
 type ListNotificationsParam struct {
+	// Appli narrows the list to notifications of a single appli. It is sent
+	// to the API, which filters server-side, and the returned profiles are
+	// also filtered client-side so the result never includes other applis.
 	Appli *int `json:"appli"`
 }
 
@@ -78,7 +202,13 @@ type ListNotificationsResp struct {
 	Body        *ListNotificationsRespBody `json:"body"`
 	RawResponse []byte
 	Path        string
+	FromCache   bool
+	RateLimit   *RateLimit
+	NoData      bool
 	Error       string
+	// Warnings describe problems that didn't fail the request, such as a
+	// request URL too long to be sent in the query.
+	Warnings []string
 }
 
 // ListNotificationsRespBody represents the notification list body.
@@ -88,11 +218,28 @@ type ListNotificationsRespBody struct {
 
 // NotificationProfile is a notification profile for the user.
 type NotificationProfile struct {
+	Appli         int        `json:"appli"`
+	CallbackURL   string     `json:"callbackurl"`
 	Expires       int64      `json:"expires"`
 	Comment       string     `json:"comment"`
 	ExpiresParsed *time.Time `json:"expiresparsed"`
 }
 
+// UnmarshalJSON decodes the profile, accepting an expiry sent as a string.
+func (p *NotificationProfile) UnmarshalJSON(data []byte) error {
+	type plain NotificationProfile
+	raw := struct {
+		*plain
+		Expires flexInt `json:"expires"`
+	}{plain: (*plain)(p), Expires: flexInt(p.Expires)}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	p.Expires = int64(raw.Expires)
+	return nil
+}
+
 // CreateNotificationParam provides the query parameters nessasary to create a notication
 // via the Withings API.
 type CreateNotificationParam struct {
@@ -102,11 +249,19 @@ type CreateNotificationParam struct {
 }
 
 // CreateNotificationResp provides the response of the create request.
+// Withings doesn't return an identifier for the created subscription; it is
+// identified by its callback URL and appli, which is what
+// GetNotificationInformation and RevokeNotification take.
 type CreateNotificationResp struct {
 	Status      status.Status `json:"status"`
 	Error       string        `json:"error"`
 	RawResponse []byte
 	Path        string
+	FromCache   bool
+	RateLimit   *RateLimit
+	// Warnings describe problems that didn't fail the request, such as a
+	// callback URL too long to be sent in the query.
+	Warnings []string
 }
 
 // SleepSummaryQueryParam provides the query parameters for requests of sleep
@@ -119,6 +274,10 @@ type SleepSummaryQueryParam struct {
 	EndDateYMD   *time.Time `json:"enddateymd"`
 	LastUpdate   *int64     `json:"lastupdate"`
 	Offset       *int       `json:"offset"`
+	// DataFields selects the metrics returned in each summary's data. If
+	// empty, the API returns its default set, which doesn't include snoring
+	// or breathing disturbances.
+	DataFields []sleepsummaryfield.Field `json:"data_fields"`
 }
 
 // SleepMeasuresQueryParam acts as the config parameter for sleep measures requests.
@@ -126,6 +285,11 @@ type SleepMeasuresQueryParam struct {
 	UserID    int       `json:"userid"`
 	StartDate time.Time `json:"startdate"`
 	EndDate   time.Time `json:"enddate"`
+	// LastUpdate, if set, limits the response to segments modified since
+	// then, to pull new data incrementally. StartDate and EndDate are
+	// required by the API and are always sent, so LastUpdate only narrows
+	// the segments within them.
+	LastUpdate *time.Time `json:"lastupdate"`
 }
 
 // SleepSummaryResp represents the unmarshelled api response for sleep summary.
@@ -134,23 +298,34 @@ type SleepSummaryResp struct {
 	Body        *SleepSummaryBody `json:"body"`
 	RawResponse []byte
 	Path        string
+	FromCache   bool
+	RateLimit   *RateLimit
+	NoData      bool
 	Error       string
+	// Warnings describe problems that didn't fail the request, such as a
+	// truncated response.
+	Warnings []string
 }
 
 // SleepSummaryBody represents the unmarshelled api response for the sleep summary body.
 type SleepSummaryBody struct {
 	Series []SleepSummary `json:"series"`
 	More   bool           `json:"more"`
+	Offset int            `json:"offset"`
 }
 
 // SleepSummary is a summary of one sleep entry.
 type SleepSummary struct {
-	ID              int64            `json:"id"`
-	StartDate       int64            `json:"startdate"`
-	EndDate         int64            `json:"enddate"`
-	Date            string           `json:"date"`
-	TimeZone        string           `json:"timezone"`
-	Model           int              `json:"model"`
+	ID        int64  `json:"id"`
+	StartDate int64  `json:"startdate"`
+	EndDate   int64  `json:"enddate"`
+	Date      string `json:"date"`
+	TimeZone  string `json:"timezone"`
+	// Model is the kind of device the entry comes from; see
+	// SleepModelTracker and SleepModelSleepAnalyzer.
+	Model int `json:"model"`
+	// ModelID is the exact device model.
+	ModelID         int              `json:"model_id"`
 	Data            SleepSummaryData `json:"data"`
 	Modified        int64            `json:"modified"`
 	StartDateParsed *time.Time       `json:"startdateparsed"`
@@ -158,6 +333,26 @@ type SleepSummary struct {
 	DateParsed      *time.Time       `json:"dateparsed"`
 }
 
+// UnmarshalJSON decodes the sleep summary, accepting timestamps sent as
+// strings.
+func (ss *SleepSummary) UnmarshalJSON(data []byte) error {
+	type plain SleepSummary
+	raw := struct {
+		*plain
+		StartDate flexInt `json:"startdate"`
+		EndDate   flexInt `json:"enddate"`
+		Modified  flexInt `json:"modified"`
+	}{plain: (*plain)(ss), StartDate: flexInt(ss.StartDate), EndDate: flexInt(ss.EndDate), Modified: flexInt(ss.Modified)}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	ss.StartDate = int64(raw.StartDate)
+	ss.EndDate = int64(raw.EndDate)
+	ss.Modified = int64(raw.Modified)
+	return nil
+}
+
 // SleepSummaryData contains the summary data for the sleep summary. Not all fields are required
 // so some are pointers and can be nil.
 type SleepSummaryData struct {
@@ -168,6 +363,16 @@ type SleepSummaryData struct {
 	WakeUpCount        int  `json:"wakeupcount"`
 	DurationToSleep    int  `json:"durationtosleep"`
 	DurationToWakeUp   *int `json:"durationtowakeup"`
+	// Snoring is the time spent snoring, in seconds, and SnoringEpisodeCount
+	// the number of snoring episodes. Both are only reported if requested.
+	Snoring             *int `json:"snoring"`
+	SnoringEpisodeCount *int `json:"snoringepisodecount"`
+	// BreathingDisturbancesIntensity is the intensity of breathing
+	// disturbances during the night, and ApneaHypopneaIndex the number of
+	// apnea and hypopnea events per hour. Both are only reported by devices
+	// that measure them, and only if requested.
+	BreathingDisturbancesIntensity *int `json:"breathing_disturbances_intensity"`
+	ApneaHypopneaIndex             *int `json:"apnea_hypopnea_index"`
 }
 
 // SleepMeasuresResp represents the unmarshelled api response for sleep measures.
@@ -176,22 +381,40 @@ type SleepMeasuresResp struct {
 	Body        *SleepMeasuresRespBody `json:"body"`
 	RawResponse []byte
 	Path        string
+	FromCache   bool
+	RateLimit   *RateLimit
+	NoData      bool
 	Error       string
+	// Warnings describe problems that didn't fail the request, such as a
+	// request URL too long to be sent in the query.
+	Warnings []string
 }
 
 // SleepMeasuresRespBody actrepresents the unmarshelled api response for sleep measures body.
+// Model is the kind of device the data comes from; see SleepModelTracker and
+// SleepModelSleepAnalyzer.
 type SleepMeasuresRespBody struct {
 	Series []SleepMeasure `json:"series"`
 	Model  int            `json:"model"`
 }
 
+// Kinds of device sleep data can come from, as found in the Model field of
+// sleep responses. Some data, such as snoring, is only recorded by the Sleep
+// Analyzer.
+const (
+	SleepModelTracker       = 16
+	SleepModelSleepAnalyzer = 32
+)
+
 // SleepMeasure is a specific instance of sleep returned by the API.
 type SleepMeasure struct {
-	StartDate       int64                 `json:"startdate"`
-	EndDate         int64                 `json:"enddate"`
-	State           sleepstate.SleepState `json:"state"`
-	StartDateParsed *time.Time            `json:"startdateparsed"`
-	EndDateParsed   *time.Time            `'json:"enddateparsed"`
+	StartDate int64                 `json:"startdate"`
+	EndDate   int64                 `json:"enddate"`
+	State     sleepstate.SleepState `json:"state"`
+	// ModelID identifies the exact device model that recorded the sleep.
+	ModelID         int        `json:"model_id"`
+	StartDateParsed *time.Time `json:"startdateparsed"`
+	EndDateParsed   *time.Time `'json:"enddateparsed"`
 }
 
 // IntradayActivityQueryParam acts as the config parameter for intraday activity retrieval requests.
@@ -199,6 +422,9 @@ type IntradayActivityQueryParam struct {
 	UserID    int        `json:"userid"`
 	StartDate *time.Time `json:"startdate"`
 	EndDate   *time.Time `json:"enddate"`
+	// DataFields restricts the metrics returned to the given ones. If empty,
+	// the API returns all of them.
+	DataFields []intradayfield.Field `json:"data_fields"`
 }
 
 // IntradayActivityResp represents the unmarshelled api response for intraday activities.
@@ -208,6 +434,12 @@ type IntradayActivityResp struct {
 	Body        *IntradayActivityRespBody `json:"body"`
 	RawResponse []byte
 	Path        string
+	FromCache   bool
+	RateLimit   *RateLimit
+	NoData      bool
+	// Warnings describe problems that didn't fail the request, such as a
+	// request URL too long to be sent in the query.
+	Warnings []string
 }
 
 // IntradayActivityRespBody represents the unmarshelled api response body for intraday activities.
@@ -215,6 +447,23 @@ type IntradayActivityRespBody struct {
 	Series map[int64]IntraDayActivity `json:"series"`
 }
 
+// UnmarshalJSON decodes the body, accepting an empty array as the series. The
+// API sends one when there are no samples in the requested window.
+func (b *IntradayActivityRespBody) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Series json.RawMessage `json:"series"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	b.Series = nil
+	if len(raw.Series) == 0 || isEmptyJSONArray(raw.Series) {
+		return nil
+	}
+	return json.Unmarshal(raw.Series, &b.Series)
+}
+
 // IntraDayActivity represents an intra day activity as returned by the API.
 // Their is likey work to be done here as the documentation does not provide
 // musch information reegarding what paramters it should contain.
@@ -225,6 +474,9 @@ type IntraDayActivity struct {
 	Elevation *float64 `json:"elevation"`
 	Steps     *int     `json:"steps"`
 	PoolLap   *int     `json:"pool_lap"`
+	HeartRate *int     `json:"heart_rate"`
+	Stroke    *int     `json:"stroke"`
+	SpO2Auto  *float64 `json:"spo2_auto"`
 }
 
 // WorkoutsQueryParam acts as the config parameter for workout retrieval requests.
@@ -232,6 +484,14 @@ type WorkoutsQueryParam struct {
 	UserID       int        `json:"userid"`
 	StartDateYMD *time.Time `json:"startdateymd"`
 	EndDateYMD   *time.Time `json:"enddateymd"`
+	// DataFields selects the metrics returned in the Data of each workout.
+	// If empty, the API decides which to return, which may leave out metrics
+	// such as the heart rate zones, so request them explicitly when they are
+	// needed.
+	DataFields []workoutfield.Field `json:"data_fields"`
+	// Offset is the offset returned by a previous response that had more
+	// workouts.
+	Offset *int `json:"offset"`
 }
 
 // WorkoutResponse represents the unmarshelled api response for workouts.
@@ -240,38 +500,190 @@ type WorkoutResponse struct {
 	Body        *WorkoutRespBody `json:"body"`
 	RawResponse []byte
 	Path        string
+	FromCache   bool
+	RateLimit   *RateLimit
+	NoData      bool
 	Error       string
+	// Warnings describe problems that didn't fail the request, such as a
+	// truncated response.
+	Warnings []string
 }
 
 // WorkoutRespBody represents the unmarshelled body of the workout api resposne.
 type WorkoutRespBody struct {
 	Series []Workout `json:"series"`
+	More   bool      `json:"more"`
+	Offset int       `json:"offset"`
 }
 
 // Workout contains each workout entry as returned by the API. The raw dates are provided
 // but fully parsed timeTime structs can be accessed via the same name as the field
 // but with Parsed added. i.e. StartDate => StartDateParsed
 type Workout struct {
-	ID              int                      `json:"id"`
-	UserID          int                      `json:"userid"`
-	Category        *workouttype.WorkoutType `json:"category"`
-	StartDate       int64                    `json:"startdate"`
-	EndDate         int64                    `json:"enddate"`
-	Model           int                      `json:"model"`
-	Attrib          int                      `json:"attrib"`
-	Date            string                   `json:"date"`
-	TimeZone        string                   `json:"timezone"`
-	Modified        int                      `json:"modified"`
-	Data            map[string]float64       `json:"data"`
-	StartDateParsed *time.Time               `json:"startdateparsed"`
-	EndDateParsed   *time.Time               `json:"enddateparsed"`
-	DateParsed      *time.Time               `json:"dateparsed"`
+	ID        int                      `json:"id"`
+	UserID    int                      `json:"userid"`
+	Category  *workouttype.WorkoutType `json:"category"`
+	StartDate int64                    `json:"startdate"`
+	EndDate   int64                    `json:"enddate"`
+	Model     int                      `json:"model"`
+	// Attrib tells how the workout was recorded; see IsAutoDetected.
+	Attrib          int                `json:"attrib"`
+	Date            string             `json:"date"`
+	TimeZone        string             `json:"timezone"`
+	Modified        int                `json:"modified"`
+	Data            map[string]float64 `json:"data"`
+	StartDateParsed *time.Time         `json:"startdateparsed"`
+	EndDateParsed   *time.Time         `json:"enddateparsed"`
+	DateParsed      *time.Time         `json:"dateparsed"`
+}
+
+// UnmarshalJSON decodes the workout, accepting timestamps sent as strings.
+func (w *Workout) UnmarshalJSON(data []byte) error {
+	type plain Workout
+	raw := struct {
+		*plain
+		StartDate flexInt `json:"startdate"`
+		EndDate   flexInt `json:"enddate"`
+		Modified  flexInt `json:"modified"`
+	}{plain: (*plain)(w), StartDate: flexInt(w.StartDate), EndDate: flexInt(w.EndDate), Modified: flexInt(w.Modified)}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	w.StartDate = int64(raw.StartDate)
+	w.EndDate = int64(raw.EndDate)
+	w.Modified = int(raw.Modified)
+	return nil
+}
+
+// HeartListQueryParam acts as the config parameter for listing heart
+// recordings. All fields are optional. Offset is the offset returned by a
+// previous response that had more recordings.
+type HeartListQueryParam struct {
+	StartDate *time.Time `json:"startdate"`
+	EndDate   *time.Time `json:"enddate"`
+	Offset    *int       `json:"offset"`
+}
+
+// HeartListResp represents the unmarshelled api response for heart recordings.
+type HeartListResp struct {
+	Status      status.Status      `json:"status"`
+	Body        *HeartListRespBody `json:"body"`
+	RawResponse []byte
+	Path        string
+	FromCache   bool
+	RateLimit   *RateLimit
+	NoData      bool
+	Error       string
+	// Warnings describe problems that didn't fail the request, such as a
+	// truncated response.
+	Warnings []string
+}
+
+// HeartListRespBody represents the unmarshelled body of the heart list api
+// response. If More is set, the remaining recordings can be requested by
+// passing Offset in the next query.
+type HeartListRespBody struct {
+	Series []HeartRecording `json:"series"`
+	More   bool             `json:"more"`
+	Offset int              `json:"offset"`
+}
+
+// Values of HeartECG.AFib.
+const (
+	AFibNegative     = 0
+	AFibPositive     = 1
+	AFibInconclusive = 2
+)
+
+// HeartRecording is a heart measurement taken by a device such as an ECG
+// capable watch or blood pressure monitor. The raw timestamp is provided, and
+// its parsed form is in TimestampParsed.
+type HeartRecording struct {
+	DeviceID        string             `json:"deviceid"`
+	Model           int                `json:"model"`
+	ECG             HeartECG           `json:"ecg"`
+	BloodPressure   HeartBloodPressure `json:"bloodpressure"`
+	HeartRate       int                `json:"heart_rate"`
+	Timestamp       int64              `json:"timestamp"`
+	TimeZone        string             `json:"timezone"`
+	TimestampParsed *time.Time         `json:"timestampparsed"`
+}
+
+// HeartECG describes the ECG of a heart recording. SignalID identifies the
+// signal, which is zero if no ECG was recorded, and AFib is its atrial
+// fibrillation classification, one of the AFib constants.
+type HeartECG struct {
+	SignalID int64 `json:"signalid"`
+	AFib     int   `json:"afib"`
+}
+
+// HeartBloodPressure is the blood pressure of a heart recording, in mmHg. Both
+// values are zero if it wasn't measured.
+type HeartBloodPressure struct {
+	Diastole int `json:"diastole"`
+	Systole  int `json:"systole"`
+}
+
+// HeartSignalResp represents the unmarshelled api response for the signal of
+// a heart recording.
+type HeartSignalResp struct {
+	Status      status.Status        `json:"status"`
+	Body        *HeartSignalRespBody `json:"body"`
+	RawResponse []byte
+	Path        string
+	FromCache   bool
+	RateLimit   *RateLimit
+	Error       string
+	// Warnings describe problems that didn't fail the request, such as a
+	// request URL too long to be sent in the query.
+	Warnings []string
+}
+
+// HeartSignalRespBody is the ECG signal of a heart recording. Signal holds
+// the samples, in microvolts, taken SamplingFrequency times per second.
+// WearPosition is where the device was worn while recording.
+type HeartSignalRespBody struct {
+	Signal            []int `json:"signal"`
+	SamplingFrequency int   `json:"sampling_frequency"`
+	WearPosition      int   `json:"wearposition"`
+}
+
+// UnmarshalJSON decodes the signal, accepting the empty array the API sends
+// in place of an empty object.
+func (b *HeartSignalRespBody) UnmarshalJSON(data []byte) error {
+	if isEmptyJSONArray(data) {
+		return nil
+	}
+	type plain HeartSignalRespBody
+	return json.Unmarshal(data, (*plain)(b))
+}
+
+// Millivolts returns the samples of the signal converted to millivolts.
+func (b HeartSignalRespBody) Millivolts() []float64 {
+	mv := make([]float64, len(b.Signal))
+	for i, s := range b.Signal {
+		mv[i] = float64(s) / 1000
+	}
+	return mv
+}
+
+// SampleInterval returns the time between two samples of the signal, or zero
+// if the sampling frequency isn't known.
+func (b HeartSignalRespBody) SampleInterval() time.Duration {
+	if b.SamplingFrequency <= 0 {
+		return 0
+	}
+	return time.Second / time.Duration(b.SamplingFrequency)
 }
 
 // ActivityMeasuresQueryParam acts as the config parameter for activity measurement queries.
 // All options feilds can be set to null but at least one of the date fields need to be
 // specified or the API will fail. Additionally there is no ParseResponse option as
 // there is no need to because the activities response doesn't need further parsing.
+// If EndDateYMD is nil, the range ends today in the client's DefaultTimezone or,
+// if that is unset, in the timezone furthest ahead of UTC, so that the user's
+// readings from earlier today are always included.
 type ActivityMeasuresQueryParam struct {
 	UserID int `json:"userid"`
 	// Date             *time.Time `json:"date"`
@@ -291,6 +703,12 @@ type ActivitiesMeasuresResp struct {
 	Body        *ActivitiesMeasuresRespBody `json:"body"`
 	RawResponse []byte
 	Path        string
+	FromCache   bool
+	RateLimit   *RateLimit
+	NoData      bool
+	// Warnings describe problems that didn't fail the request, such as a
+	// truncated response.
+	Warnings []string
 }
 
 // ActivitiesMeasuresRespBody contains the response body as provided by the
@@ -328,22 +746,59 @@ type Activity struct {
 	TimeZone   string     `json:"timezone"`
 }
 
+// Limits on the number of measure groups returned by a single body measures
+// request. DefaultBodyMeasuresLimit is used when no Limit is given, and a
+// larger Limit than MaxBodyMeasuresLimit is clamped with a warning.
+const (
+	DefaultBodyMeasuresLimit = 1000
+	MaxBodyMeasuresLimit     = 1000
+)
+
 // BodyMeasuresQueryParams acts as the config parameter for body measurement queries.
 // All optional field can be set to null.
 // The ParsedResponse can be set to true and the request will automatically parse
 // the response into easy to use structs. Otherwise this can be done manually when
 // needed via the Parse method.
 type BodyMeasuresQueryParams struct {
-	UserID        int                `json:"userid"`
-	StartDate     *time.Time         `json:"startdate"`
-	EndDate       *time.Time         `json:"enddate"`
-	LastUpdate    *time.Time         `json:"lastupdate"`
-	DevType       *devtype.DevType   `json:"devtype"`
-	MeasType      *meastype.MeasType `json:"meastype"`
-	Category      *int               `json:"category"`
-	Limit         *int               `json:"limit"`
-	Offset        *int               `json:"offset"`
+	UserID int `json:"userid"`
+	// StartDate and EndDate are sent as instants; to select whole calendar
+	// days in the user's timezone, use DaysIn(start, end, loc).BodyMeasuresParams().
+	StartDate  *time.Time         `json:"startdate"`
+	EndDate    *time.Time         `json:"enddate"`
+	LastUpdate *time.Time         `json:"lastupdate"`
+	DevType    *devtype.DevType   `json:"devtype"`
+	MeasType   *meastype.MeasType `json:"meastype"`
+	// MeasTypes requests several measure types at once, alongside MeasType.
+	MeasTypes     []meastype.MeasType `json:"meastypes"`
+	Category      *int                `json:"category"`
+	Limit         *int                `json:"limit"`
+	Offset        *int                `json:"offset"`
 	ParseResponse bool
+
+	// SortDescending orders the returned measure groups, and therefore the
+	// parsed response, newest first. The API has no ordering parameter so
+	// the sort is performed client-side once the response is received.
+	SortDescending bool
+}
+
+// Values of BodyMeasuresQueryParams.Category and MeasureGroup.Category.
+const (
+	// MeasureCategoryReal selects measures actually taken by the user.
+	MeasureCategoryReal = 1
+	// MeasureCategoryObjective selects the user's objectives, such as a
+	// target weight.
+	MeasureCategoryObjective = 2
+)
+
+// RealMeasuresOfType returns query params selecting only real measures of
+// type t, e.g. the user's actual weights rather than their target weight. The
+// API applies both filters together.
+func RealMeasuresOfType(t meastype.MeasType) *BodyMeasuresQueryParams {
+	category := MeasureCategoryReal
+	return &BodyMeasuresQueryParams{
+		MeasType: &t,
+		Category: &category,
+	}
 }
 
 // BodyMeasuresResp contains the unmarshalled response from the api.
@@ -354,36 +809,182 @@ type BodyMeasuresResp struct {
 	Body           *BodyMeasureRespBody `json:"body"`
 	RawResponse    []byte
 	Path           string
+	FromCache      bool
+	RateLimit      *RateLimit
+	NoData         bool
 	ParsedResponse *BodyMeasures
 	Error          string
+	// Warnings describe problems that didn't fail the request, such as
+	// malformed measures that were skipped.
+	Warnings []string
 }
 
 // BodyMeasureRespBody represents the body portion of the body measure response.
 // The body portion is not required and thus this may not be found in the response
 // object.
 type BodyMeasureRespBody struct {
-	Updatetime  int64                  `json:"updatetime"`
-	More        int                    `json:"more"`
-	Timezone    string                 `json:"timezone"`
-	MeasureGrps []BodyMeasureGroupResp `json:"measuregrps"`
+	Updatetime int64 `json:"updatetime"`
+	// More is non-zero if the API has further measure groups beyond this
+	// page, and Offset is then the offset to request them from. Together
+	// they allow paging to be resumed later, e.g. from a stored offset after
+	// a crash. GetAllBodyMeasuresCtx follows them itself.
+	More        int            `json:"more"`
+	Offset      int            `json:"offset"`
+	Timezone    string         `json:"timezone"`
+	MeasureGrps []MeasureGroup `json:"measuregrps"`
+
+	// warnings describe malformed groups and measures skipped while
+	// decoding. GetBodyMeasuresCtx reports them in BodyMeasuresResp.Warnings.
+	warnings []string
 }
 
-// BodyMeasureGroupResp is a single body measurment group as found in the resposne.
-// Each group has a set of measures that can then be parsed manually or via the
-// Parse method on BodyMeasuresQueryParams.
-type BodyMeasureGroupResp struct {
-	GrpID    int                   `json:"grpid"`
-	Attrib   int                   `json:"attrib"`
-	Date     int64                 `json:"date"`
-	Category int                   `json:"category"`
-	Measures []BodyMeasuresMeasure `json:"measures"`
+// UnmarshalJSON decodes the body one measure group, and one measure, at a
+// time. A malformed group or measure is skipped and recorded in warnings so
+// the remaining data can still be used.
+func (b *BodyMeasureRespBody) UnmarshalJSON(data []byte) error {
+	type plain BodyMeasureRespBody
+	var raw struct {
+		plain
+		MeasureGrps []json.RawMessage `json:"measuregrps"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*b = BodyMeasureRespBody(raw.plain)
+	b.MeasureGrps = nil
+	b.warnings = nil
+	for i, rawGroup := range raw.MeasureGrps {
+		var group struct {
+			MeasureGroup
+			Date     flexInt           `json:"date"`
+			Created  flexInt           `json:"created"`
+			Modified flexInt           `json:"modified"`
+			Measures []json.RawMessage `json:"measures"`
+		}
+		if err := json.Unmarshal(rawGroup, &group); err != nil {
+			b.warnings = append(b.warnings, fmt.Sprintf("skipped malformed measure group %d: %v", i, err))
+			continue
+		}
+
+		g := group.MeasureGroup
+		g.Date = int64(group.Date)
+		g.Created = int64(group.Created)
+		g.Modified = int64(group.Modified)
+		g.Measures = nil
+		for j, rawMeasure := range group.Measures {
+			var m Measure
+			if err := json.Unmarshal(rawMeasure, &m); err != nil {
+				b.warnings = append(b.warnings, fmt.Sprintf("skipped malformed measure %d of group %d: %v", j, g.GrpID, err))
+				continue
+			}
+			g.Measures = append(g.Measures, m)
+		}
+		b.MeasureGrps = append(b.MeasureGrps, g)
+	}
+	return nil
+}
+
+// MeasureGroup is a single group of body measures as found in the response. A
+// group holds every measure taken in one session on one device, e.g. the
+// weight, fat ratio and heart rate from a single weigh-in. ParseData flattens
+// groups into per-type slices, but the groups can also be used directly.
+type MeasureGroup struct {
+	// GrpID uniquely identifies the group.
+	GrpID int `json:"grpid"`
+	// Attrib describes how the measures were captured, e.g. by a device or
+	// entered manually.
+	Attrib int `json:"attrib"`
+	// Date is the UNIX time at which the measures were taken.
+	Date int64 `json:"date"`
+	// Created is the UNIX time at which the group was stored by Withings.
+	Created int64 `json:"created"`
+	// Modified is the UNIX time at which the group was last changed.
+	Modified int64 `json:"modified"`
+	// Timezone is the IANA timezone the measures were taken in. It may be
+	// empty, in which case the timezone of the response body applies.
+	Timezone string `json:"timezone"`
+	// Category is MeasureCategoryReal for real measures and
+	// MeasureCategoryObjective for user objectives.
+	Category int `json:"category"`
+	// ModelID identifies the exact device model that took the measures, such
+	// as a particular scale, where DevType only gives the kind of device. It
+	// is zero for measures not taken by a device.
+	ModelID int `json:"modelid"`
+	// DeviceID is the hashed identifier of the device that took the
+	// measures. It is empty for measures not taken by a device, such as
+	// those entered manually.
+	DeviceID string `json:"deviceid"`
+	// Comment is the note the user attached to the measures in the Withings
+	// app. Withings only documents it as optional and usually sends null, so
+	// it is empty for most groups.
+	Comment string `json:"comment"`
+	// Measures are the individual values taken in this session.
+	Measures []Measure `json:"measures"`
+}
+
+// Time returns the time at which the measures of the group were taken.
+func (g MeasureGroup) Time() time.Time {
+	return time.Unix(g.Date, 0)
+}
+
+// Measure returns the real value of the first measure of type t in the group,
+// converted as per Measure.Float, and whether the group has such a measure.
+// The value is expressed in the unit given by meastype.CanonicalUnit, e.g. kg
+// for muscle and bone mass.
+func (g MeasureGroup) Measure(t meastype.MeasType) (float64, bool) {
+	for _, m := range g.Measures {
+		if m.Type == t {
+			return m.Float(), true
+		}
+	}
+	return 0, false
 }
 
-// BodyMeasuresMeasure is a single body measure found in the response.
-type BodyMeasuresMeasure struct {
-	Value int               `json:"value"`
-	Type  meastype.MeasType `json:"type"`
-	Unit  int               `json:"unit"`
+// BodyMeasureGroupResp is the previous name of MeasureGroup.
+type BodyMeasureGroupResp = MeasureGroup
+
+// Measure is a single body measure found in a MeasureGroup. The real value is
+// Value * 10^Unit, expressed in the canonical unit of Type; see Float.
+type Measure struct {
+	// Value is the measured value, scaled by Unit.
+	Value int `json:"value"`
+	// Type is the kind of measure, e.g. weight or heart pulse.
+	Type meastype.MeasType `json:"type"`
+	// Unit is the power of ten Value must be multiplied by.
+	Unit int `json:"unit"`
+}
+
+// UnmarshalJSON decodes the measure, accepting numbers sent as strings.
+func (m *Measure) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Value flexInt `json:"value"`
+		Type  flexInt `json:"type"`
+		Unit  flexInt `json:"unit"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*m = Measure{Value: int(raw.Value), Type: meastype.MeasType(raw.Type), Unit: int(raw.Unit)}
+	return nil
+}
+
+// Float returns the real value of the measure, Value * 10^Unit.
+func (m Measure) Float() float64 {
+	return convertUnits(m.Value, m.Unit)
+}
+
+// BodyMeasuresMeasure is the previous name of Measure.
+type BodyMeasuresMeasure = Measure
+
+// Measurement is the real value of a single measure together with when it was
+// taken. Value is expressed in the unit given by meastype.CanonicalUnit.
+type Measurement struct {
+	Date     time.Time
+	Value    float64
+	Attrib   int
+	Category int
 }
 
 type Weight struct {
@@ -666,7 +1267,13 @@ func (rm BodyMeasuresResp) ParseData() *BodyMeasures {
 	return &bm
 }
 
-// convertUnits converts the value to the units specified.
+// convertUnits converts the value to the units specified. The sign of value is
+// preserved. Negative units divide by the exact power of ten rather than
+// multiplying by its inexact reciprocal, so e.g. 72345 with unit -3 is exactly
+// the float nearest 72.345.
 func convertUnits(value int, unit int) float64 {
-	return float64(value) * math.Pow(10, float64(unit))
+	if unit < 0 {
+		return float64(value) / math.Pow10(-unit)
+	}
+	return float64(value) * math.Pow10(unit)
 }
@@ -4,21 +4,59 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
+	"github.com/asymmetricia/withings/enum/status"
 	"golang.org/x/oauth2"
 )
 
+// ErrRefreshTokenConsumed is returned when Withings rejects a refresh token
+// because it has already been used or has otherwise been invalidated. Withings
+// issues a new refresh token on every refresh, so this usually means a stale
+// token was loaded from storage. The user must re-authorize the application.
+var ErrRefreshTokenConsumed = errors.New("refresh token has already been used or is no longer valid")
+
+// refreshTokenError is ErrRefreshTokenConsumed together with the error
+// Withings answered the refresh with, which it unwraps to, so callers can test
+// for either.
+type refreshTokenError struct {
+	err error
+}
+
+func (e *refreshTokenError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrRefreshTokenConsumed, e.err)
+}
+
+func (e *refreshTokenError) Is(target error) bool {
+	return target == ErrRefreshTokenConsumed
+}
+
+func (e *refreshTokenError) Unwrap() error {
+	return e.err
+}
+
 // User is a Withings Health user account that can be interacted with via the
 // api. A user object should not be copied.
 type User struct {
 	*Client
 	OauthToken *oauth2.Token
 	HTTPClient *http.Client
+
+	// OnTokenRefresh, if set, is called with the new token after each
+	// successful refresh of the user's access token, so that a rotated refresh
+	// token can be persisted. It is never called with a nil token. Refreshes
+	// made while the user is being created aren't reported, as the new token
+	// is then already on the returned user.
+	OnTokenRefresh func(*oauth2.Token)
+
+	// backoff pauses the user's requests after one of them is rate limited.
+	backoff backoffGate
 }
 
 // NewUserFromAccessToken returns a user with the given access token. If it's
@@ -37,7 +75,7 @@ func (c *Client) NewUserFromAccessToken(ctx context.Context, accessToken string,
 
 	u.HTTPClient = &http.Client{Transport: u}
 
-	if u.OauthToken.Expiry.Before(time.Now()) {
+	if u.OauthToken.Expiry.Before(c.now()) {
 		return c.NewUserFromRefreshToken(ctx, refreshToken)
 	}
 
@@ -66,6 +104,41 @@ func (c *Client) NewUserFromRefreshToken(ctx context.Context, refreshToken strin
 	return u, nil
 }
 
+// NewUserFromTokenJSON returns a user with the token encoded in data, as
+// produced by User.TokenJSON, without making any request. As with
+// NewUserFromAccessToken, an expired access token is refreshed on first use.
+func (c *Client) NewUserFromTokenJSON(data []byte) (*User, error) {
+	t := &oauth2.Token{}
+	if err := json.Unmarshal(data, t); err != nil {
+		return nil, fmt.Errorf("decoding token JSON: %w", err)
+	}
+	if t.AccessToken == "" && t.RefreshToken == "" {
+		return nil, errors.New("decoding token JSON: no access or refresh token")
+	}
+
+	u := &User{
+		Client:     c,
+		OauthToken: t,
+	}
+	u.HTTPClient = &http.Client{Transport: u}
+	return u, nil
+}
+
+// TokenJSON returns the user's current token encoded as JSON, for persisting
+// and later restoring with NewUserFromTokenJSON. The token isn't refreshed
+// first. The granted scopes aren't included, so Scopes returns nil for the
+// restored user until its token is next refreshed.
+func (u *User) TokenJSON() ([]byte, error) {
+	if u.OauthToken == nil {
+		return nil, errors.New("encoding token JSON: user has no token")
+	}
+	data, err := json.Marshal(u.OauthToken)
+	if err != nil {
+		return nil, fmt.Errorf("encoding token JSON: %w", err)
+	}
+	return data, nil
+}
+
 // Token returns the user's oauth token, refreshing it if necessary. After a call
 // to Token, the OauthToken field may have changed, and RefreshToken should
 // persisted if so.
@@ -76,69 +149,158 @@ func (u *User) Token() (*oauth2.Token, error) {
 // TokenContext is as per Token, above, but accepts a context, which will be used
 // for API calls if necessary.
 func (u *User) TokenContext(ctx context.Context) (*oauth2.Token, error) {
-	if u.OauthToken.Expiry.After(time.Now()) {
+	if u.OauthToken.Expiry.After(u.Client.now()) {
 		return u.OauthToken, nil
 	}
 
 	// Refresh the token
 	form := url.Values{}
-	form.Set("action", "requesttoken")
+	form.Set("action", string(ActionRequestToken))
 	form.Set("client_id", u.OAuth2Config.ClientID)
 	form.Set("client_secret", u.OAuth2Config.ClientSecret)
 	form.Set("grant_type", "refresh_token")
 	form.Set("refresh_token", u.OauthToken.RefreshToken)
 	body := bytes.NewBufferString(form.Encode())
 
-	req, err := http.NewRequest("POST", "https://wbsapi.withings.net/v2/oauth2", body)
+	req, err := http.NewRequest("POST", u.Client.tokenURL(), body)
 	if err != nil {
 		return nil, fmt.Errorf("producing new request in TokenContext: %w", err)
 	}
 
+	setExtraHeaders(req, u.Client.ExtraHeaders, requestOptionsFrom(ctx).header)
 	req.Header.Set("content-type", "application/x-www-form-urlencoded")
 
-	res, err := (*WithingsRoundTripper)(http.DefaultClient).RoundTrip(req.WithContext(ctx))
+	res, err := u.Client.tokenTransport().RoundTrip(req.WithContext(ctx))
 	if err != nil {
+		if refreshTokenRejected(err) {
+			return nil, &refreshTokenError{err: err}
+		}
 		return nil, fmt.Errorf("sending request in TokenContext: %w", err)
 	}
 	defer res.Body.Close()
 
 	if res.StatusCode != 200 {
 		body, _ := ioutil.ReadAll(res.Body)
-		return nil, fmt.Errorf("non-2XX %d from server in TokenContext: %q", res.StatusCode, string(body))
+		return nil, &NetworkError{StatusCode: res.StatusCode, Err: fmt.Errorf("in TokenContext: %q", string(body))}
 	}
 
-	var response struct {
-		UserId       UserId `json:"userid"`
-		AccessToken  string `json:"access_token"`
-		RefreshToken string `json:"refresh_token"`
-		ExpiresIn    int    `json:"expires_in"`
-		Scope        string `json:"scope"`
-		CsrfToken    string `json:"csrf_token"`
-		TokenType    string `json:"token_type"`
-	}
+	var response tokenResponse
 
 	resBody, err := ioutil.ReadAll(res.Body)
 	if err != nil {
-		return nil, fmt.Errorf("reading body in TokenContext: %w", err)
+		return nil, &NetworkError{Err: fmt.Errorf("reading body in TokenContext: %w", err)}
 	}
 
 	if err := json.Unmarshal(resBody, &response); err != nil {
 		return nil, fmt.Errorf("decoding body in TokenContext: %w", err)
 	}
 
-	u.OauthToken = &oauth2.Token{
-		AccessToken:  response.AccessToken,
-		TokenType:    response.TokenType,
-		RefreshToken: response.RefreshToken,
-		Expiry:       time.Now().Add(time.Duration(response.ExpiresIn) * time.Second),
+	if err := response.validate(); err != nil {
+		return nil, fmt.Errorf("in TokenContext: %w", err)
+	}
+
+	old := u.OauthToken
+	u.OauthToken = response.token(u.Client.now())
+	if u.Client.TokenRefreshed != nil {
+		u.Client.TokenRefreshed(TokenRefreshEvent{
+			UserID:              string(response.UserId),
+			OldExpiry:           old.Expiry,
+			NewExpiry:           u.OauthToken.Expiry,
+			RefreshTokenRotated: u.OauthToken.RefreshToken != old.RefreshToken,
+		})
+	}
+	if u.OnTokenRefresh != nil {
+		u.OnTokenRefresh(u.OauthToken)
 	}
 	return u.OauthToken, nil
 }
 
+// TokenRefreshEvent describes a refresh of a user's access token, for
+// Client.TokenRefreshed. It deliberately holds no token values.
+type TokenRefreshEvent struct {
+	// UserID is the Withings id of the user, as reported with the new token.
+	UserID string
+	// OldExpiry is the expiry of the replaced access token. It is zero if it
+	// wasn't known, such as for a user created from a refresh token.
+	OldExpiry time.Time
+	// NewExpiry is the expiry of the new access token.
+	NewExpiry time.Time
+	// RefreshTokenRotated is true if Withings issued a new refresh token,
+	// which must then be persisted in place of the old one.
+	RefreshTokenRotated bool
+}
+
+// NeedsRefresh reports whether the user's access token will have expired
+// within the given duration from now, without refreshing it. A token with no
+// known expiry needs refreshing, just as TokenContext would refresh it.
+func (u *User) NeedsRefresh(within time.Duration) bool {
+	if u.OauthToken == nil {
+		return true
+	}
+	return !u.OauthToken.Expiry.After(u.Client.now().Add(within))
+}
+
+// refreshTokenRejected reports whether err indicates that Withings refused the
+// refresh token itself, as opposed to a transient or unrelated failure.
+// Withings answers a reused or revoked refresh token with an "invalid params"
+// status whose message names the refresh token.
+func refreshTokenRejected(err error) bool {
+	var se *APIError
+	if !errors.As(err, &se) {
+		return false
+	}
+
+	switch se.Status {
+	case status.TokenIsInvalidOrDoesntExist:
+		return true
+	case status.InvalidParams:
+		msg := strings.ToLower(se.Message)
+		return strings.Contains(msg, "refresh_token") || strings.Contains(msg, "invalid_grant")
+	}
+	return false
+}
+
 func (u *User) RoundTrip(req *http.Request) (resp *http.Response, err error) {
-	return oauth2.NewClient(req.Context(), u).
-		Transport.
-		RoundTrip(req)
+	base := u.Client.transport()
+	if hc, ok := req.Context().Value(oauth2.HTTPClient).(*http.Client); ok && hc.Transport != nil {
+		base = hc.Transport
+	}
+
+	return (&oauth2.Transport{Source: u, Base: base}).RoundTrip(req)
 }
 
 var _ oauth2.TokenSource = (*User)(nil)
+
+// Scopes returns the scopes the user granted, as reported by Withings when the
+// current access token was issued. It returns nil if they aren't known, such
+// as for a user created from an access token that hasn't been refreshed yet.
+func (u *User) Scopes() []Scope {
+	if u.OauthToken == nil {
+		return nil
+	}
+	granted, _ := u.OauthToken.Extra("scope").(string)
+	if granted == "" {
+		return nil
+	}
+
+	var scopes []Scope
+	for _, s := range strings.FieldsFunc(granted, func(r rune) bool { return r == ',' || r == ' ' }) {
+		scopes = append(scopes, Scope(s))
+	}
+	return scopes
+}
+
+// HasScope reports whether the user granted scope. If the granted scopes
+// aren't known, it optimistically returns true.
+func (u *User) HasScope(scope Scope) bool {
+	scopes := u.Scopes()
+	if scopes == nil {
+		return true
+	}
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
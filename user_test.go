@@ -0,0 +1,254 @@
+package withings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+func TestRefreshTokenRejected(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		rejected bool
+	}{
+		{"consumed", `{"status":503,"body":{},"error":"Invalid Params: invalid refresh_token"}`, true},
+		{"invalid token", `{"status":283,"body":{},"error":"Token is invalid or doesn't exist"}`, true},
+		{"other invalid params", `{"status":503,"body":{},"error":"Invalid Params: invalid client_id"}`, false},
+		{"rate limited", `{"status":601,"body":{},"error":"Too Many Requests"}`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				fmt.Fprint(rw, tt.body)
+			}))
+			defer srv.Close()
+
+			req, err := http.NewRequest("POST", srv.URL, nil)
+			require.NoError(t, err)
+
+			_, err = (*WithingsRoundTripper)(http.DefaultClient).RoundTrip(req)
+			require.Error(t, err)
+			require.Equal(t, tt.rejected, refreshTokenRejected(err))
+			require.False(t, refreshTokenRejected(errors.New(tt.body)))
+		})
+	}
+}
+
+func TestRefreshTokenConsumedKeepsAPIError(t *testing.T) {
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(rw, `{"status":503,"body":{},"error":"Invalid Params: invalid refresh_token"}`)
+	})
+	u.OauthToken.Expiry = time.Now().Add(-time.Hour)
+
+	_, err := u.TokenContext(context.Background())
+	require.ErrorIs(t, err, ErrRefreshTokenConsumed)
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	require.EqualValues(t, 503, apiErr.Status)
+	require.Contains(t, apiErr.Message, "refresh_token")
+}
+
+func TestScopeCheck(t *testing.T) {
+	var calls int32
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		fmt.Fprint(rw, `{"status":0,"body":{}}`)
+	})
+	u.OauthToken = tokenResponse{
+		AccessToken: "access-token",
+		ExpiresIn:   3600,
+		Scope:       "user.info,user.metrics",
+		UserId:      "1234",
+	}.token(time.Now())
+
+	require.Equal(t, []Scope{ScopeUserInfo, ScopeUserMetrics}, u.Scopes())
+	require.Equal(t, "1234", u.OauthToken.Extra("userid"))
+
+	_, err := u.GetIntradayActivity(nil)
+	var scopeErr *ScopeError
+	require.True(t, errors.As(err, &scopeErr))
+	require.Equal(t, ScopeUserActivity, scopeErr.Required)
+	require.EqualValues(t, 0, atomic.LoadInt32(&calls))
+
+	_, err = u.GetBodyMeasures(nil)
+	require.NoError(t, err)
+	require.EqualValues(t, 1, atomic.LoadInt32(&calls))
+
+	// Without a recorded scope, nothing is checked.
+	u.OauthToken = u.OauthToken.WithExtra(map[string]interface{}{})
+	require.Nil(t, u.Scopes())
+	_, err = u.GetIntradayActivity(nil)
+	require.NoError(t, err)
+}
+
+func TestRefreshUsesBaseURL(t *testing.T) {
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/v2/oauth2":
+			require.NoError(t, req.ParseForm())
+			require.Equal(t, "refresh_token", req.PostForm.Get("grant_type"))
+			require.Equal(t, "refresh-token", req.PostForm.Get("refresh_token"))
+			fmt.Fprint(rw, `{"status":0,"body":{"userid":1234,"access_token":"new-access","refresh_token":"new-refresh","expires_in":10800,"scope":"user.metrics","token_type":"Bearer"}}`)
+		case "/measure":
+			require.Equal(t, "Bearer new-access", req.Header.Get("Authorization"))
+			fmt.Fprint(rw, `{"status":0,"body":{}}`)
+		default:
+			t.Errorf("unexpected path %q", req.URL.Path)
+		}
+	})
+	u.OauthToken.Expiry = time.Now().Add(-time.Minute)
+
+	_, err := u.GetBodyMeasures(nil)
+	require.NoError(t, err)
+	require.Equal(t, "new-refresh", u.OauthToken.RefreshToken)
+	require.Equal(t, []Scope{ScopeUserMetrics}, u.Scopes())
+}
+
+func TestRefreshRejectsNonBearerToken(t *testing.T) {
+	for tokenType, ok := range map[string]bool{"Bearer": true, "bearer": true, "mac": false} {
+		t.Run(tokenType, func(t *testing.T) {
+			u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+				fmt.Fprintf(rw, `{"status":0,"body":{"access_token":"new-access","refresh_token":"new-refresh","expires_in":10800,"token_type":%q}}`, tokenType)
+			})
+			u.OauthToken.Expiry = time.Now().Add(-time.Minute)
+
+			_, err := u.TokenContext(context.Background())
+			if ok {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "mac")
+			}
+		})
+	}
+}
+
+func TestNeedsRefresh(t *testing.T) {
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		t.Error("NeedsRefresh must not send requests")
+	})
+	u.OauthToken.Expiry = time.Now().Add(30 * time.Minute)
+	token := *u.OauthToken
+
+	require.False(t, u.NeedsRefresh(0))
+	require.False(t, u.NeedsRefresh(10*time.Minute))
+	require.True(t, u.NeedsRefresh(time.Hour))
+	require.Equal(t, token, *u.OauthToken)
+
+	u.OauthToken.Expiry = time.Time{}
+	require.True(t, u.NeedsRefresh(0))
+}
+
+func TestTokenRefreshedEvent(t *testing.T) {
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(rw, `{"status":0,"body":{"userid":1234,"access_token":"new-access","refresh_token":"new-refresh","expires_in":10800,"token_type":"Bearer"}}`)
+	})
+	oldExpiry := time.Now().Add(-time.Minute)
+	u.OauthToken.Expiry = oldExpiry
+
+	var events []TokenRefreshEvent
+	u.Client.TokenRefreshed = func(e TokenRefreshEvent) {
+		events = append(events, e)
+	}
+
+	_, err := u.TokenContext(context.Background())
+	require.NoError(t, err)
+	_, err = u.TokenContext(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, events, 1)
+	require.Equal(t, "1234", events[0].UserID)
+	require.Equal(t, oldExpiry, events[0].OldExpiry)
+	require.Equal(t, u.OauthToken.Expiry, events[0].NewExpiry)
+	require.True(t, events[0].RefreshTokenRotated)
+}
+
+func TestOnTokenRefresh(t *testing.T) {
+	fail := false
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		if fail {
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		fmt.Fprint(rw, `{"status":0,"body":{"userid":1234,"access_token":"new-access","refresh_token":"new-refresh","expires_in":10800,"token_type":"Bearer"}}`)
+	})
+	u.OauthToken.Expiry = time.Now().Add(-time.Minute)
+
+	var tokens []*oauth2.Token
+	u.OnTokenRefresh = func(t *oauth2.Token) {
+		tokens = append(tokens, t)
+	}
+
+	_, err := u.TokenContext(context.Background())
+	require.NoError(t, err)
+	_, err = u.TokenContext(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, tokens, 1)
+	require.NotNil(t, tokens[0])
+	require.Equal(t, "new-refresh", tokens[0].RefreshToken)
+	require.Equal(t, u.OauthToken, tokens[0])
+
+	fail = true
+	u.OauthToken.Expiry = time.Now().Add(-time.Minute)
+	_, err = u.TokenContext(context.Background())
+	require.Error(t, err)
+	require.Len(t, tokens, 1)
+}
+
+func TestTokenJSONRoundTrip(t *testing.T) {
+	var calls int32
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		fmt.Fprint(rw, `{"status":0,"body":{"series":[]}}`)
+	})
+
+	data, err := u.TokenJSON()
+	require.NoError(t, err)
+
+	restored, err := u.Client.NewUserFromTokenJSON(data)
+	require.NoError(t, err)
+	require.EqualValues(t, 0, atomic.LoadInt32(&calls))
+	require.Equal(t, u.OauthToken.AccessToken, restored.OauthToken.AccessToken)
+	require.Equal(t, u.OauthToken.RefreshToken, restored.OauthToken.RefreshToken)
+	require.True(t, u.OauthToken.Expiry.Equal(restored.OauthToken.Expiry))
+
+	_, err = restored.GetSleepSummary(nil)
+	require.NoError(t, err)
+
+	_, err = u.Client.NewUserFromTokenJSON([]byte(`{}`))
+	require.Error(t, err)
+	_, err = u.Client.NewUserFromTokenJSON([]byte(`not json`))
+	require.Error(t, err)
+}
+
+func TestNewUserFromAccessTokenRefreshesOnlyExpired(t *testing.T) {
+	var refreshes int32
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		require.Equal(t, "/v2/oauth2", req.URL.Path)
+		atomic.AddInt32(&refreshes, 1)
+		fmt.Fprint(rw, `{"status":0,"body":{"userid":1234,"access_token":"new-access","refresh_token":"new-refresh","expires_in":10800,"scope":"user.metrics","token_type":"Bearer"}}`)
+	})
+	c := u.Client
+
+	valid, err := c.NewUserFromAccessToken(context.Background(), "access", time.Now().Add(time.Hour), "refresh")
+	require.NoError(t, err)
+	require.EqualValues(t, 0, atomic.LoadInt32(&refreshes))
+	require.Equal(t, "access", valid.OauthToken.AccessToken)
+
+	expired, err := c.NewUserFromAccessToken(context.Background(), "access", time.Now().Add(-time.Minute), "refresh")
+	require.NoError(t, err)
+	require.EqualValues(t, 1, atomic.LoadInt32(&refreshes))
+	require.Equal(t, "new-access", expired.OauthToken.AccessToken)
+	require.Equal(t, "new-refresh", expired.OauthToken.RefreshToken)
+}
@@ -8,28 +8,39 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/asymmetricia/withings/enum/status"
 	"golang.org/x/oauth2"
 )
 
+// DefaultBaseURL is the scheme and host of the Withings API, used for both
+// data and token requests unless Client.BaseURL is set.
+const DefaultBaseURL = "https://wbsapi.withings.net"
+
+// Paths of the API endpoints, relative to the base URL.
 const (
-	apiHost                       = "wbsapi.withings.net"
-	getIntradayActivitiesURL      = "https://" + apiHost + "/v2/measure"
-	getActivityMeasuresURL        = "https://" + apiHost + "/v2/measure"
-	getWorkoutsURL                = "https://" + apiHost + "/v2/measure"
-	getBodyMeasureURL             = "https://" + apiHost + "/measure"
-	getSleepMeasureURL            = "https://" + apiHost + "/v2/sleep"
-	getSleepSummaryURL            = "https://" + apiHost + "/v2/sleep"
-	createNotficationURL          = "https://" + apiHost + "/notify"
-	listNotificationsURL          = "https://" + apiHost + "/notify"
-	getNotificationInformationURL = "https://" + apiHost + "/notify"
-	revokeNotificationURL         = "https://" + apiHost + "/notify"
+	tokenPath                      = "/v2/oauth2"
+	getIntradayActivitiesPath      = "/v2/measure"
+	getActivityMeasuresPath        = "/v2/measure"
+	getWorkoutsPath                = "/v2/measure"
+	getBodyMeasurePath             = "/measure"
+	getSleepMeasurePath            = "/v2/sleep"
+	getSleepSummaryPath            = "/v2/sleep"
+	createNotficationPath          = "/notify"
+	listNotificationsPath          = "/notify"
+	getNotificationInformationPath = "/notify"
+	revokeNotificationPath         = "/notify"
+	getDevicesPath                 = "/v2/user"
+	getHeartListPath               = "/v2/heart"
+	getHeartSignalPath             = "/v2/heart"
 )
 
 // Scope defines the types of scopes accepted by the API.
@@ -44,6 +55,42 @@ const (
 	ScopeUserActivity Scope = "user.activity"
 )
 
+// Action is the name of an API action, sent as the action parameter of every
+// request. Several endpoints reuse the same name, e.g. "get" is both the sleep
+// and the notification information action.
+type Action string
+
+const (
+	// ActionRequestToken exchanges an authorization code or refresh token for an access token.
+	ActionRequestToken Action = "requesttoken"
+	// ActionGetIntradayActivity retrieves intraday activity samples.
+	ActionGetIntradayActivity Action = "getintradayactivity"
+	// ActionGetActivity retrieves daily activity summaries.
+	ActionGetActivity Action = "getactivity"
+	// ActionGetWorkouts retrieves workouts.
+	ActionGetWorkouts Action = "getworkouts"
+	// ActionGetMeas retrieves body measures.
+	ActionGetMeas Action = "getmeas"
+	// ActionGetSleep retrieves sleep measures.
+	ActionGetSleep Action = "get"
+	// ActionGetSleepSummary retrieves sleep summaries.
+	ActionGetSleepSummary Action = "getsummary"
+	// ActionSubscribe creates a notification.
+	ActionSubscribe Action = "subscribe"
+	// ActionListNotifications lists the notifications of a user.
+	ActionListNotifications Action = "list"
+	// ActionGetNotification retrieves a single notification.
+	ActionGetNotification Action = "get"
+	// ActionRevokeNotification revokes a notification.
+	ActionRevokeNotification Action = "revoke"
+	// ActionGetDevices lists the devices of a user.
+	ActionGetDevices Action = "getdevice"
+	// ActionListHeart lists heart recordings.
+	ActionListHeart Action = "list"
+	// ActionGetHeartSignal retrieves the ECG signal of a heart recording.
+	ActionGetHeartSignal Action = "get"
+)
+
 // Rand provides a function type to allow passing in custom random functions
 // used for state generation.
 type Rand func() (string, error)
@@ -63,8 +110,90 @@ type Client struct {
 	IncludePath     bool
 	Rand            Rand
 	Timeout         time.Duration
+
+	// DefaultTimezone is used for records whose timezone is empty or isn't a
+	// recognized IANA name. If nil, UTC is used.
+	DefaultTimezone *time.Location
+
+	// Transport is the HTTP transport API requests are sent with. NewClient
+	// sets it to one created by NewTransport, so that establishing a
+	// connection is bounded separately from the overall Timeout. If nil,
+	// http.DefaultTransport is used.
+	Transport http.RoundTripper
+
+	// BaseURL is the scheme and host that data and token requests are sent
+	// to, such as a regional API host or a mock server. If empty,
+	// DefaultBaseURL is used. The authorization page is configured separately
+	// by Endpoint.
+	BaseURL string
+
+	// Endpoint holds the authorization and token URLs of this client.
+	// NewClient sets it to Oauth2Endpoint; it takes the place of
+	// OAuth2Config.Endpoint, so clients can target different environments
+	// without changing the package-level default. A TokenURL left at the
+	// default follows BaseURL. If AuthURL is empty, OAuth2Config.Endpoint is
+	// used instead.
+	Endpoint oauth2.Endpoint
+
+	// ExtraHeaders are set on every request sent to the API, such as a
+	// partner header. The Authorization header is never overridden. Headers
+	// for individual requests can be set with WithHeader.
+	ExtraHeaders http.Header
+
+	// Limiter, if set, paces the requests sent to the API, e.g. to stay
+	// within the rate limit of the application.
+	Limiter Limiter
+
+	// MaxRetries is the number of times a rate limited request, one the API
+	// answered with status 601 or HTTP 429, is retried before its error is
+	// returned. Only requests that read data are retried. Retries wait as
+	// long as the response's Retry-After header asks or, if it doesn't say,
+	// BackoffBase doubled for every attempt: 1s, 2s, 4s... if BackoffBase is
+	// zero. By default requests aren't retried.
+	MaxRetries  int
+	BackoffBase time.Duration
+
+	// StrictEnums makes requests fail with an *UnknownEnumError when a
+	// response holds an enum value, such as a measure type, workout category
+	// or sleep state, that this package doesn't know. By default such values
+	// are returned as is.
+	StrictEnums bool
+
+	// TokenRefreshed, if set, is called after every successful refresh of a
+	// user's access token, e.g. to keep an audit trail. It must not block.
+	TokenRefreshed func(TokenRefreshEvent)
+
+	// StateStore, if set, records the state of every URL generated by
+	// AuthCodeURL for NewUserFromRedirect to check. NewClient sets it to a
+	// MemoryStateStore. States expire after StateTTL, or DefaultStateTTL if
+	// it is zero.
+	StateStore StateStore
+	StateTTL   time.Duration
+
+	// recordDir and replayDir are set by RecordTo and ReplayFrom.
+	recordDir string
+	replayDir string
+
+	state *clientState
 }
 
+// clientState is state shared by every copy of a Client created by NewClient.
+type clientState struct {
+	// skew is the estimated clock skew in nanoseconds, accessed atomically.
+	skew int64
+
+	// mu guards the fields below, which track requests for Shutdown.
+	mu       sync.Mutex
+	shutdown bool
+	inFlight int
+	// idle is closed once no requests are in flight after Shutdown.
+	idle chan struct{}
+}
+
+// DefaultConnectTimeout bounds connecting to the API and waiting for the
+// response headers on transports created by NewClient.
+const DefaultConnectTimeout = 5 * time.Second
+
 // NewClient creates a new client using the Ouath2 information provided. The
 // required parameters can be obtained when developers register with Withings
 // to use the API.
@@ -78,9 +207,92 @@ func NewClient(clientID string, clientSecret string, redirectURL string) Client
 			Scopes:   []string{"user.activity,user.metrics,user.info"},
 			Endpoint: Oauth2Endpoint,
 		},
-		Rand:    generateRandomString,
-		Timeout: 5 * time.Second,
+		Rand:       generateRandomString,
+		Timeout:    5 * time.Second,
+		Transport:  NewTransport(DefaultConnectTimeout),
+		BaseURL:    DefaultBaseURL,
+		Endpoint:   Oauth2Endpoint,
+		StateStore: NewMemoryStateStore(),
+		state:      &clientState{},
+	}
+}
+
+// NewTransport returns an HTTP transport on which dialing, the TLS handshake and
+// waiting for the response headers are each bounded by connectTimeout. Reading
+// the response body is not bounded by the transport, so a large response is
+// only limited by the deadline of the request's context.
+func NewTransport(connectTimeout time.Duration) *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   connectTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   connectTimeout,
+		ResponseHeaderTimeout: connectTimeout,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
+
+// SetTimeouts sets the time allowed to connect to the API and receive response
+// headers separately from the total time allowed for a request, including
+// reading the body. Large requests such as multi-day intraday pulls can take
+// far longer to transfer than to start, so they call for a generous total with
+// a short connect timeout. The total only applies to methods that don't accept
+// a context. This is not thread safe and should be done on client creation.
+func (c *Client) SetTimeouts(connect, total time.Duration) {
+	c.Timeout = total
+	c.Transport = NewTransport(connect)
+}
+
+// apiURL returns the URL of the endpoint at path under the client's base URL.
+func (c *Client) apiURL(path string) string {
+	base := c.BaseURL
+	if base == "" {
+		base = DefaultBaseURL
 	}
+	return strings.TrimSuffix(base, "/") + path
+}
+
+// tokenURL returns the URL token requests are sent to: Endpoint.TokenURL if
+// it has been changed from the default, and the token path on BaseURL
+// otherwise.
+func (c *Client) tokenURL() string {
+	if c.Endpoint.TokenURL != "" && c.Endpoint.TokenURL != DefaultBaseURL+tokenPath {
+		return c.Endpoint.TokenURL
+	}
+	return c.apiURL(tokenPath)
+}
+
+// oauth2Config returns OAuth2Config with the client's Endpoint.
+func (c *Client) oauth2Config() *oauth2.Config {
+	if c.Endpoint.AuthURL == "" {
+		return c.OAuth2Config
+	}
+	config := *c.OAuth2Config
+	config.Endpoint = c.Endpoint
+	return &config
+}
+
+// transport returns the round tripper API requests are sent with. Clients
+// created by NewClient record the clock skew observed on its responses.
+func (c *Client) transport() http.RoundTripper {
+	next := c.Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	if c.state == nil {
+		return next
+	}
+	return skewTransport{next: next, state: c.state}
+}
+
+// tokenTransport returns the round tripper used for token requests.
+func (c *Client) tokenTransport() *WithingsRoundTripper {
+	return &WithingsRoundTripper{Transport: c.transport()}
 }
 
 // SetScope allows for setting the scope of the client which is used during
@@ -103,10 +315,24 @@ func (c *Client) getContext() (context.Context, context.CancelFunc) {
 //
 // The state parameter of the request is generated using crypto/rand
 // and returned as state. The random generation function can be replaced
-// by assigning a new function to Client.Rand.
-func (c *Client) AuthCodeURL() (url string, state string, err error) {
+// by assigning a new function to Client.Rand. Additional parameters can be
+// added to the URL with opts, e.g. oauth2.SetAuthURLParam. The state is
+// saved in the client's StateStore, if it has one.
+func (c *Client) AuthCodeURL(opts ...oauth2.AuthCodeOption) (url string, state string, err error) {
 	state, err = c.Rand()
-	return c.OAuth2Config.AuthCodeURL(state), state, err
+	if err == nil {
+		err = c.saveState(state)
+	}
+	return c.oauth2Config().AuthCodeURL(state, opts...), state, err
+}
+
+// AuthCodeURLForceConsent is as per AuthCodeURL, but asks for the consent
+// screen to be shown even if the user has already authorized the application,
+// e.g. so they can grant a scope they previously denied. It adds the standard
+// OAuth prompt=consent parameter, which is ignored by providers that don't
+// support forcing the consent screen.
+func (c *Client) AuthCodeURLForceConsent() (url string, state string, err error) {
+	return c.AuthCodeURL(oauth2.SetAuthURLParam("prompt", "consent"))
 }
 
 // GenerateAccessToken generates the access token from the authorization code. The
@@ -116,7 +342,7 @@ func (c *Client) AuthCodeURL() (url string, state string, err error) {
 // calling methods.
 func (c *Client) GenerateAccessToken(ctx context.Context, code string) (*oauth2.Token, error) {
 	form := url.Values{}
-	form.Set("action", "requesttoken")
+	form.Set("action", string(ActionRequestToken))
 	form.Set("client_id", c.OAuth2Config.ClientID)
 	form.Set("client_secret", c.OAuth2Config.ClientSecret)
 	form.Set("grant_type", "authorization_code")
@@ -124,12 +350,13 @@ func (c *Client) GenerateAccessToken(ctx context.Context, code string) (*oauth2.
 	form.Set("redirect_uri", c.OAuth2Config.RedirectURL)
 	body := bytes.NewBufferString(form.Encode())
 
-	req, err := http.NewRequest("POST", "https://wbsapi.withings.net/v2/oauth2", body)
+	req, err := http.NewRequest("POST", c.tokenURL(), body)
 	if err != nil {
 		return nil, fmt.Errorf("producing new request: %w", err)
 	}
+	setExtraHeaders(req, c.ExtraHeaders, requestOptionsFrom(ctx).header)
 
-	res, err := (*WithingsRoundTripper)(http.DefaultClient).RoundTrip(req.WithContext(ctx))
+	res, err := c.tokenTransport().RoundTrip(req.WithContext(ctx))
 	if err != nil {
 		return nil, fmt.Errorf("sending request: %w", err)
 	}
@@ -137,34 +364,25 @@ func (c *Client) GenerateAccessToken(ctx context.Context, code string) (*oauth2.
 
 	if res.StatusCode != 200 {
 		body, _ := ioutil.ReadAll(res.Body)
-		return nil, fmt.Errorf("non-2XX %d from server: %q", res.StatusCode, string(body))
+		return nil, &NetworkError{StatusCode: res.StatusCode, Err: fmt.Errorf("%q", string(body))}
 	}
 
-	var response struct {
-		UserId       UserId `json:"userid"`
-		AccessToken  string `json:"access_token"`
-		RefreshToken string `json:"refresh_token"`
-		ExpiresIn    int    `json:"expires_in"`
-		Scope        string `json:"scope"`
-		CsrfToken    string `json:"csrf_token"`
-		TokenType    string `json:"token_type"`
-	}
+	var response tokenResponse
 
 	resBody, err := ioutil.ReadAll(res.Body)
 	if err != nil {
-		return nil, fmt.Errorf("reading body: %w", err)
+		return nil, &NetworkError{Err: fmt.Errorf("reading body: %w", err)}
 	}
 
 	if err := json.Unmarshal(resBody, &response); err != nil {
 		return nil, fmt.Errorf("decoding body: %w", err)
 	}
 
-	return &oauth2.Token{
-		AccessToken:  response.AccessToken,
-		TokenType:    response.TokenType,
-		RefreshToken: response.RefreshToken,
-		Expiry:       time.Now().Add(time.Duration(response.ExpiresIn) * time.Second),
-	}, nil
+	if err := response.validate(); err != nil {
+		return nil, err
+	}
+
+	return response.token(c.now()), nil
 }
 
 // WithingsRoundTripper unwraps withings responses so the oauth2 library can
@@ -174,7 +392,7 @@ type WithingsRoundTripper http.Client
 func (w *WithingsRoundTripper) RoundTrip(request *http.Request) (*http.Response, error) {
 	res, err := (*http.Client)(w).Do(request)
 	if err != nil {
-		return nil, err
+		return nil, networkError(err)
 	}
 
 	if res.StatusCode != 200 {
@@ -185,12 +403,13 @@ func (w *WithingsRoundTripper) RoundTrip(request *http.Request) (*http.Response,
 
 	var response struct {
 		Status int
+		Error  string
 		Body   json.RawMessage
 	}
 
 	resBody, err := ioutil.ReadAll(res.Body)
 	if err != nil {
-		return nil, fmt.Errorf("reading response body: %w", err)
+		return nil, &NetworkError{Err: fmt.Errorf("reading response body: %w", err)}
 	}
 
 	if err := json.Unmarshal(resBody, &response); err != nil {
@@ -198,13 +417,11 @@ func (w *WithingsRoundTripper) RoundTrip(request *http.Request) (*http.Response,
 	}
 
 	if response.Status != 0 {
-		return nil, fmt.Errorf(
-			"bad status code %d in body, see "+
-				"https://developer.withings.com/api-reference/#section/Response-status"+
-				" -- full body was: %q",
-			response.Status,
-			string(resBody),
-		)
+		return nil, &APIError{
+			Status:  status.Status(response.Status),
+			Message: response.Error,
+			Body:    resBody,
+		}
 	}
 
 	res.Body = ioutil.NopCloser(bytes.NewBuffer(response.Body))
@@ -213,18 +430,48 @@ func (w *WithingsRoundTripper) RoundTrip(request *http.Request) (*http.Response,
 
 var _ http.RoundTripper = (*WithingsRoundTripper)(nil)
 
+// decodeResponse unmarshals an API response into v. When a query matches no
+// data, some actions respond with an empty array as the body rather than an
+// object; such a body is treated as absent so the response still decodes.
+func decodeResponse(data []byte, v interface{}) error {
+	err := json.Unmarshal(data, v)
+	if err == nil {
+		return nil
+	}
+
+	var envelope map[string]json.RawMessage
+	if json.Unmarshal(data, &envelope) != nil || !isEmptyJSONArray(envelope["body"]) {
+		return err
+	}
+
+	delete(envelope, "body")
+	data, err = json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// isEmptyJSONArray reports whether data is the JSON array [].
+func isEmptyJSONArray(data json.RawMessage) bool {
+	return string(bytes.Join(bytes.Fields(data), nil)) == "[]"
+}
+
 // NewUserFromAuthCode generates a new user by requesting the token using the
 // authentication code provided. This is generally only used after a user
 // has just authorized access and the client is processing the redirect.
+// The exchange is bounded by the deadline of ctx, if it has one, and otherwise
+// by the client's Timeout.
 func (c *Client) NewUserFromAuthCode(ctx context.Context, code string) (*User, error) {
-	ctx = context.WithValue(ctx, oauth2.HTTPClient, &http.Client{
-		Transport: (*WithingsRoundTripper)(http.DefaultClient),
-		Timeout:   c.Timeout,
-	})
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
 
 	t, err := c.GenerateAccessToken(ctx, code)
 	if err != nil {
-		return nil, fmt.Errorf("failed to obtain token: %s", err)
+		return nil, fmt.Errorf("failed to obtain token: %w", err)
 	}
 
 	u := &User{
@@ -250,7 +497,7 @@ func (u *User) GetIntradayActivityCtx(ctx context.Context, params *IntradayActiv
 
 	// Building query params
 	v := url.Values{}
-	v.Add("action", "getintradayactivity")
+	v.Add("action", string(ActionGetIntradayActivity))
 
 	if params != nil {
 		if params.StartDate != nil {
@@ -259,44 +506,45 @@ func (u *User) GetIntradayActivityCtx(ctx context.Context, params *IntradayActiv
 		if params.EndDate != nil {
 			v.Add(GetFieldName(*params, "EndDate"), strconv.FormatInt(params.EndDate.Unix(), 10))
 		}
+		if len(params.DataFields) > 0 {
+			fields, err := joinDataFields("intraday", params.DataFields)
+			if err != nil {
+				return intraDayActivityResponse, err
+			}
+			v.Add(GetFieldName(*params, "DataFields"), fields)
+		}
 	}
 
 	// Sending request to the API.
-	path := fmt.Sprintf("%s?%s", getIntradayActivitiesURL, v.Encode())
+	res, err := u.send(ctx, getIntradayActivitiesPath, v)
 	if u.Client.IncludePath {
-		intraDayActivityResponse.Path = path
+		intraDayActivityResponse.Path = res.Path
 	}
-
-	req, err := http.NewRequest("GET", path, nil)
-	req = req.WithContext(ctx)
-	if err != nil {
-		return intraDayActivityResponse, fmt.Errorf("failed to build request: %s", err)
-	}
-
-	resp, err := u.HTTPClient.Do(req)
 	if err != nil {
 		return intraDayActivityResponse, err
 	}
-	defer resp.Body.Close()
+	intraDayActivityResponse.FromCache = res.FromCache
+	intraDayActivityResponse.RateLimit = res.RateLimit
+	intraDayActivityResponse.Warnings = append(intraDayActivityResponse.Warnings, res.Warnings...)
 
 	// Processing API response.
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return intraDayActivityResponse, err
-	}
 	if u.Client.SaveRawResponse {
-		intraDayActivityResponse.RawResponse = body
+		intraDayActivityResponse.RawResponse = res.Body
 	}
 
-	err = json.Unmarshal(body, &intraDayActivityResponse)
+	err = decodeResponse(res.Body, &intraDayActivityResponse)
 	if err != nil {
 		return intraDayActivityResponse, err
 	}
 	if intraDayActivityResponse.Status != status.OperationWasSuccessful {
-		return intraDayActivityResponse, fmt.Errorf("api returned an error: %s", intraDayActivityResponse.Error)
+		return intraDayActivityResponse, &APIError{Status: intraDayActivityResponse.Status, Message: intraDayActivityResponse.Error, Body: res.Body}
+	}
+	if intraDayActivityResponse.Body == nil {
+		intraDayActivityResponse.Body = &IntradayActivityRespBody{}
 	}
 
-	return intraDayActivityResponse, nil
+	intraDayActivityResponse.NoData = len(intraDayActivityResponse.Body.Series) == 0
+	return intraDayActivityResponse, noData(ctx, intraDayActivityResponse.NoData)
 }
 
 // GetActivityMeasures is the same as GetActivityMeasuresCtx but doesn't require a context to be provided.
@@ -314,8 +562,9 @@ func (u *User) GetActivityMeasuresCtx(ctx context.Context, params *ActivityMeasu
 
 	// Building the query params
 	v := url.Values{}
-	v.Add("action", "getactivity")
+	v.Add("action", string(ActionGetActivity))
 
+	today := u.Client.today()
 	if params != nil {
 		// if params.Date != nil {
 		// 	v.Add(GetFieldName(*params, "Date"), params.Date.Format("2006-01-02"))
@@ -323,65 +572,58 @@ func (u *User) GetActivityMeasuresCtx(ctx context.Context, params *ActivityMeasu
 		if params.StartDateYMD != nil {
 			v.Add(GetFieldName(*params, "StartDateYMD"), params.StartDateYMD.Format("2006-01-02"))
 		} else {
-			v.Add(GetFieldName(*params, "StartDateYMD"), time.Now().AddDate(0, 0, -1).Format("2006-01-02"))
+			v.Add(GetFieldName(*params, "StartDateYMD"), today.AddDate(0, 0, -1).Format("2006-01-02"))
 		}
 		if params.EndDateYMD != nil {
 			v.Add(GetFieldName(*params, "EndDateYMD"), params.EndDateYMD.Format("2006-01-02"))
 		} else {
-			v.Add(GetFieldName(*params, "EndDateYMD"), time.Now().Format("2006-01-02"))
+			v.Add(GetFieldName(*params, "EndDateYMD"), today.Format("2006-01-02"))
 		}
 		if params.LasteUpdate != nil {
 			v.Add(GetFieldName(*params, "LasteUpdate"), strconv.FormatInt(params.LasteUpdate.Unix(), 10))
 		}
+		if params.Offset != nil {
+			v.Add(GetFieldName(*params, "Offset"), strconv.Itoa(*params.Offset))
+		}
 	} else {
 		params = &ActivityMeasuresQueryParam{}
-		v.Add(GetFieldName(*params, "StartDateYMD"), time.Now().AddDate(0, 0, -1).Format("2006-01-02"))
-		v.Add(GetFieldName(*params, "EndDateYMD"), time.Now().Format("2006-01-02"))
+		v.Add(GetFieldName(*params, "StartDateYMD"), today.AddDate(0, 0, -1).Format("2006-01-02"))
+		v.Add(GetFieldName(*params, "EndDateYMD"), today.Format("2006-01-02"))
 
 	}
 
 	// Sending request to the API.
-	path := fmt.Sprintf("%s?%s", getActivityMeasuresURL, v.Encode())
+	res, err := u.send(ctx, getActivityMeasuresPath, v)
 	if u.Client.IncludePath {
-		activityMeasureResponse.Path = path
-	}
-
-	req, err := http.NewRequest("GET", path, nil)
-	req = req.WithContext(ctx)
-	if err != nil {
-		return activityMeasureResponse, fmt.Errorf("failed to build request: %s", err)
+		activityMeasureResponse.Path = res.Path
 	}
-
-	resp, err := u.HTTPClient.Do(req)
 	if err != nil {
 		return activityMeasureResponse, err
 	}
-	defer resp.Body.Close()
+	activityMeasureResponse.FromCache = res.FromCache
+	activityMeasureResponse.RateLimit = res.RateLimit
+	activityMeasureResponse.Warnings = append(activityMeasureResponse.Warnings, res.Warnings...)
 
 	// Processing API response.
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return activityMeasureResponse, err
-	}
 	if u.Client.SaveRawResponse {
-		activityMeasureResponse.RawResponse = body
+		activityMeasureResponse.RawResponse = res.Body
 	}
 
-	err = json.Unmarshal(body, &activityMeasureResponse)
+	err = decodeResponse(res.Body, &activityMeasureResponse)
 	if err != nil {
 		return activityMeasureResponse, err
 	}
 
 	if activityMeasureResponse.Status != status.OperationWasSuccessful {
-		return activityMeasureResponse, fmt.Errorf("api returned an error: %s", activityMeasureResponse.Error)
+		return activityMeasureResponse, &APIError{Status: activityMeasureResponse.Status, Message: activityMeasureResponse.Error, Body: res.Body}
+	}
+	if activityMeasureResponse.Body == nil {
+		activityMeasureResponse.Body = &ActivitiesMeasuresRespBody{}
 	}
 
 	// Parse date time if possible.
 	if activityMeasureResponse.Body.Date != nil && activityMeasureResponse.Body.TimeZone != nil {
-		location, err := time.LoadLocation(*activityMeasureResponse.Body.TimeZone)
-		if err != nil {
-			return activityMeasureResponse, err
-		}
+		location := u.Client.location(*activityMeasureResponse.Body.TimeZone)
 
 		t, err := time.Parse("2006-01-02", *activityMeasureResponse.Body.Date)
 		if err != nil {
@@ -395,10 +637,7 @@ func (u *User) GetActivityMeasuresCtx(ctx context.Context, params *ActivityMeasu
 	}
 
 	for aID := range activityMeasureResponse.Body.Activities {
-		location, err := time.LoadLocation(activityMeasureResponse.Body.Activities[aID].TimeZone)
-		if err != nil {
-			return activityMeasureResponse, err
-		}
+		location := u.Client.location(activityMeasureResponse.Body.Activities[aID].TimeZone)
 
 		t, err := time.Parse("2006-01-02", activityMeasureResponse.Body.Activities[aID].Date)
 		if err != nil {
@@ -409,7 +648,9 @@ func (u *User) GetActivityMeasuresCtx(ctx context.Context, params *ActivityMeasu
 		activityMeasureResponse.Body.Activities[aID].ParsedDate = &t
 	}
 
-	return activityMeasureResponse, nil
+	activityMeasureResponse.Warnings = append(activityMeasureResponse.Warnings, truncationWarnings(ctx, activityMeasureResponse.Truncated(), activityMeasureResponse.Body.Offset)...)
+	activityMeasureResponse.NoData = !activityMeasureResponse.Body.SingleValue && len(activityMeasureResponse.Body.Activities) == 0
+	return activityMeasureResponse, noData(ctx, activityMeasureResponse.NoData)
 }
 
 // GetWorkouts is the same as GetWorkoutsCTX but doesn't require a context to be provided.
@@ -427,7 +668,7 @@ func (u *User) GetWorkoutsCtx(ctx context.Context, params *WorkoutsQueryParam) (
 
 	// Building query params
 	v := url.Values{}
-	v.Add("action", "getworkouts")
+	v.Add("action", string(ActionGetWorkouts))
 
 	if params != nil {
 		if params.StartDateYMD != nil {
@@ -436,41 +677,49 @@ func (u *User) GetWorkoutsCtx(ctx context.Context, params *WorkoutsQueryParam) (
 		if params.EndDateYMD != nil {
 			v.Add(GetFieldName(*params, "EndDateYMD"), params.EndDateYMD.Format("2006-01-02"))
 		}
+		if params.Offset != nil {
+			v.Add(GetFieldName(*params, "Offset"), strconv.Itoa(*params.Offset))
+		}
+		if len(params.DataFields) > 0 {
+			fields, err := joinDataFields("workout", params.DataFields)
+			if err != nil {
+				return workoutResponse, err
+			}
+			v.Add(GetFieldName(*params, "DataFields"), fields)
+		}
 	}
 
 	// Sending request to the API.
-	path := fmt.Sprintf("%s?%s", getWorkoutsURL, v.Encode())
+	res, err := u.send(ctx, getWorkoutsPath, v)
 	if u.Client.IncludePath {
-		workoutResponse.Path = path
-	}
-
-	req, err := http.NewRequest("GET", path, nil)
-	req = req.WithContext(ctx)
-	if err != nil {
-		return workoutResponse, fmt.Errorf("failed to build request: %s", err)
+		workoutResponse.Path = res.Path
 	}
-
-	resp, err := u.HTTPClient.Do(req)
 	if err != nil {
 		return workoutResponse, err
 	}
-	defer resp.Body.Close()
+	workoutResponse.FromCache = res.FromCache
+	workoutResponse.RateLimit = res.RateLimit
+	workoutResponse.Warnings = append(workoutResponse.Warnings, res.Warnings...)
 
 	// Processing API response.
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return workoutResponse, nil
-	}
 	if u.Client.SaveRawResponse {
-		workoutResponse.RawResponse = body
+		workoutResponse.RawResponse = res.Body
 	}
 
-	err = json.Unmarshal(body, &workoutResponse)
+	err = decodeResponse(res.Body, &workoutResponse)
 	if err != nil {
 		return workoutResponse, err
 	}
 	if workoutResponse.Status != status.OperationWasSuccessful {
-		return workoutResponse, fmt.Errorf("api returned an error: %s", workoutResponse.Error)
+		return workoutResponse, &APIError{Status: workoutResponse.Status, Message: workoutResponse.Error, Body: res.Body}
+	}
+	if workoutResponse.Body == nil {
+		workoutResponse.Body = &WorkoutRespBody{}
+	}
+	if u.Client.StrictEnums {
+		if err := workoutResponse.Body.checkEnums(); err != nil {
+			return workoutResponse, err
+		}
 	}
 
 	// Parse dates if possible
@@ -482,10 +731,7 @@ func (u *User) GetWorkoutsCtx(ctx context.Context, params *WorkoutsQueryParam) (
 			d = time.Unix(workoutResponse.Body.Series[i].EndDate, 0)
 			workoutResponse.Body.Series[i].EndDateParsed = &d
 
-			location, err := time.LoadLocation(workoutResponse.Body.Series[i].TimeZone)
-			if err != nil {
-				return workoutResponse, err
-			}
+			location := u.Client.location(workoutResponse.Body.Series[i].TimeZone)
 
 			t, err := time.Parse("2006-01-02", workoutResponse.Body.Series[i].Date)
 			if err != nil {
@@ -498,8 +744,213 @@ func (u *User) GetWorkoutsCtx(ctx context.Context, params *WorkoutsQueryParam) (
 		}
 	}
 
-	return workoutResponse, nil
+	workoutResponse.Warnings = append(workoutResponse.Warnings, truncationWarnings(ctx, workoutResponse.Truncated(), workoutResponse.Body.Offset)...)
+	workoutResponse.NoData = len(workoutResponse.Body.Series) == 0
+	return workoutResponse, noData(ctx, workoutResponse.NoData)
+
+}
+
+// GetHeartList is the same as GetHeartListCtx but doesn't require a context to be provided.
+func (u *User) GetHeartList(params *HeartListQueryParam) (HeartListResp, error) {
+	ctx, cancel := u.Client.getContext()
+	defer cancel()
+	return u.GetHeartListCtx(ctx, params)
+}
+
+// GetHeartListCtx retrieves the heart recordings, such as ECGs and blood
+// pressure measurements, for a given date range based on the values provided
+// by params.
+func (u *User) GetHeartListCtx(ctx context.Context, params *HeartListQueryParam) (HeartListResp, error) {
+	heartResponse := HeartListResp{}
+
+	// Building query params
+	v := url.Values{}
+	v.Add("action", string(ActionListHeart))
+
+	if params != nil {
+		if params.StartDate != nil {
+			v.Add(GetFieldName(*params, "StartDate"), strconv.FormatInt(params.StartDate.Unix(), 10))
+		}
+		if params.EndDate != nil {
+			v.Add(GetFieldName(*params, "EndDate"), strconv.FormatInt(params.EndDate.Unix(), 10))
+		}
+		if params.Offset != nil {
+			v.Add(GetFieldName(*params, "Offset"), strconv.Itoa(*params.Offset))
+		}
+	}
+
+	// Sending request to the API.
+	res, err := u.send(ctx, getHeartListPath, v)
+	if u.Client.IncludePath {
+		heartResponse.Path = res.Path
+	}
+	if err != nil {
+		return heartResponse, err
+	}
+	heartResponse.FromCache = res.FromCache
+	heartResponse.RateLimit = res.RateLimit
+	heartResponse.Warnings = append(heartResponse.Warnings, res.Warnings...)
+
+	// Processing API response.
+	if u.Client.SaveRawResponse {
+		heartResponse.RawResponse = res.Body
+	}
+
+	err = decodeResponse(res.Body, &heartResponse)
+	if err != nil {
+		return heartResponse, err
+	}
+	if heartResponse.Status != status.OperationWasSuccessful {
+		return heartResponse, &APIError{Status: heartResponse.Status, Message: heartResponse.Error, Body: res.Body}
+	}
+	if heartResponse.Body == nil {
+		heartResponse.Body = &HeartListRespBody{}
+	}
+
+	// Parse dates
+	for i := range heartResponse.Body.Series {
+		r := &heartResponse.Body.Series[i]
+		d := time.Unix(r.Timestamp, 0).In(u.Client.location(r.TimeZone))
+		r.TimestampParsed = &d
+	}
+
+	heartResponse.Warnings = append(heartResponse.Warnings, truncationWarnings(ctx, heartResponse.Truncated(), heartResponse.Body.Offset)...)
+	heartResponse.NoData = len(heartResponse.Body.Series) == 0
+	return heartResponse, noData(ctx, heartResponse.NoData)
+}
+
+// GetHeartSignal is the same as GetHeartSignalCtx but doesn't require a context to be provided.
+func (u *User) GetHeartSignal(signalID int64) (HeartSignalResp, error) {
+	ctx, cancel := u.Client.getContext()
+	defer cancel()
+	return u.GetHeartSignalCtx(ctx, signalID)
+}
+
+// GetHeartSignalCtx retrieves the ECG signal with the given id, as found in
+// the ECG of a heart recording returned by GetHeartListCtx. As a signal can
+// hold many thousands of samples, the response is decoded as it is read
+// rather than held in memory, unless SaveRawResponse is set.
+func (u *User) GetHeartSignalCtx(ctx context.Context, signalID int64) (HeartSignalResp, error) {
+	heartSignalResponse := HeartSignalResp{}
+
+	// Building query params
+	v := url.Values{}
+	v.Add("action", string(ActionGetHeartSignal))
+	v.Add("signalid", strconv.FormatInt(signalID, 10))
+
+	// Sending request to the API.
+	res, err := u.sendDecode(ctx, getHeartSignalPath, v, &heartSignalResponse)
+	if u.Client.IncludePath {
+		heartSignalResponse.Path = res.Path
+	}
+	if err != nil {
+		return heartSignalResponse, err
+	}
+	heartSignalResponse.FromCache = res.FromCache
+	heartSignalResponse.RateLimit = res.RateLimit
+	heartSignalResponse.Warnings = append(heartSignalResponse.Warnings, res.Warnings...)
+
+	// Processing API response.
+	if u.Client.SaveRawResponse {
+		heartSignalResponse.RawResponse = res.Body
+	}
+
+	if heartSignalResponse.Status != status.OperationWasSuccessful {
+		return heartSignalResponse, &APIError{Status: heartSignalResponse.Status, Message: heartSignalResponse.Error, Body: res.Body}
+	}
+	if heartSignalResponse.Body == nil {
+		heartSignalResponse.Body = &HeartSignalRespBody{}
+	}
+
+	return heartSignalResponse, nil
+}
+
+// WorkoutNotFoundError is returned by GetWorkout when the requested workout is
+// not present in the queried date range.
+type WorkoutNotFoundError struct {
+	ID int
+}
+
+func (e *WorkoutNotFoundError) Error() string {
+	return fmt.Sprintf("workout %d not found in the requested range", e.ID)
+}
+
+// GetWorkout is the same as GetWorkoutCtx but doesn't require a context to be provided.
+func (u *User) GetWorkout(id int, params *WorkoutsQueryParam) (*Workout, error) {
+	ctx, cancel := u.Client.getContext()
+	defer cancel()
+	return u.GetWorkoutCtx(ctx, id, params)
+}
+
+// GetWorkoutCtx retrieves a single workout by its ID. The API has no action to
+// fetch one workout, so the workouts in the date range given by params are
+// retrieved and searched for the ID. A *WorkoutNotFoundError is returned if the
+// workout isn't in that range.
+func (u *User) GetWorkoutCtx(ctx context.Context, id int, params *WorkoutsQueryParam) (*Workout, error) {
+	workoutResponse, err := u.GetWorkoutsCtx(WithRequestOptions(ctx, noDataAsError(false)), params)
+	if err != nil {
+		return nil, err
+	}
+
+	if workoutResponse.Body != nil {
+		for i := range workoutResponse.Body.Series {
+			if workoutResponse.Body.Series[i].ID == id {
+				return &workoutResponse.Body.Series[i], nil
+			}
+		}
+	}
+
+	return nil, &WorkoutNotFoundError{ID: id}
+}
+
+const (
+	// recentWorkoutsWindow is the number of days first searched by
+	// GetRecentWorkoutsCtx.
+	recentWorkoutsWindow = 30
+	// recentWorkoutsMaxWindow caps how far back GetRecentWorkoutsCtx searches.
+	recentWorkoutsMaxWindow = 365
+)
 
+// GetRecentWorkoutsCtx retrieves the user's n most recent workouts, newest
+// first, without the caller choosing a date range. The last 30 days are
+// searched first; while fewer than n workouts are found the window is doubled,
+// up to a year. Fewer than n workouts are returned if the user has no more in
+// that year.
+func (u *User) GetRecentWorkoutsCtx(ctx context.Context, n int) ([]Workout, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+
+	ctx = WithRequestOptions(ctx, noDataAsError(false))
+	end := u.Client.today()
+	var workouts []Workout
+	for days := recentWorkoutsWindow; ; days *= 2 {
+		if days > recentWorkoutsMaxWindow {
+			days = recentWorkoutsMaxWindow
+		}
+
+		start := end.AddDate(0, 0, -days)
+		workoutResponse, err := u.GetWorkoutsCtx(ctx, &WorkoutsQueryParam{
+			StartDateYMD: &start,
+			EndDateYMD:   &end,
+		})
+		if err != nil {
+			return nil, err
+		}
+		workouts = workoutResponse.Body.Series
+
+		if len(workouts) >= n || days == recentWorkoutsMaxWindow {
+			break
+		}
+	}
+
+	sort.SliceStable(workouts, func(i, j int) bool {
+		return workouts[i].StartDate > workouts[j].StartDate
+	})
+	if len(workouts) > n {
+		workouts = workouts[:n]
+	}
+	return workouts, nil
 }
 
 // GetBodyMeasures is the same as GetBodyMeasuresCtx but doesn't require a context to be provided.
@@ -516,7 +967,7 @@ func (u *User) GetBodyMeasuresCtx(ctx context.Context, params *BodyMeasuresQuery
 
 	// Building query params
 	v := url.Values{}
-	v.Add("action", "getmeas")
+	v.Add("action", string(ActionGetMeas))
 
 	if params != nil {
 		if params.StartDate != nil {
@@ -526,7 +977,7 @@ func (u *User) GetBodyMeasuresCtx(ctx context.Context, params *BodyMeasuresQuery
 			v.Add(GetFieldName(*params, "EndDate"), strconv.FormatInt(params.EndDate.Unix(), 10))
 		}
 		if params.LastUpdate != nil {
-			v.Add(GetFieldName(*params, "LastUpdate"), strconv.FormatInt(params.EndDate.Unix(), 10))
+			v.Add(GetFieldName(*params, "LastUpdate"), strconv.FormatInt(params.LastUpdate.Unix(), 10))
 		}
 		if params.DevType != nil {
 			v.Add(GetFieldName(*params, "DevType"), strconv.Itoa(int(*params.DevType)))
@@ -534,58 +985,143 @@ func (u *User) GetBodyMeasuresCtx(ctx context.Context, params *BodyMeasuresQuery
 		if params.MeasType != nil {
 			v.Add(GetFieldName(*params, "MeasType"), strconv.Itoa(int(*params.MeasType)))
 		}
+		if len(params.MeasTypes) > 0 {
+			types := make([]string, len(params.MeasTypes))
+			for i, t := range params.MeasTypes {
+				types[i] = strconv.Itoa(int(t))
+			}
+			v.Add(GetFieldName(*params, "MeasTypes"), strings.Join(types, ","))
+		}
 		if params.Category != nil {
 			v.Add(GetFieldName(*params, "Category"), strconv.Itoa(*params.Category))
 		}
-		if params.Limit != nil {
-			v.Add(GetFieldName(*params, "Limit"), strconv.Itoa(*params.Limit))
-		}
 		if params.Offset != nil {
 			v.Add(GetFieldName(*params, "Offset"), strconv.Itoa(*params.Offset))
 		}
 	}
 
-	// Sending request to the API.
-	path := fmt.Sprintf("%s?%s", getBodyMeasureURL, v.Encode())
-	if u.Client.IncludePath {
-		bodyMeasureResponse.Path = path
+	// The limit is always sent, so pages aren't needlessly small, and
+	// clamped to the maximum the API accepts.
+	limit := DefaultBodyMeasuresLimit
+	if params != nil && params.Limit != nil {
+		limit = *params.Limit
 	}
-
-	req, err := http.NewRequest("GET", path, nil)
-	req = req.WithContext(ctx)
-	if err != nil {
-		return bodyMeasureResponse, fmt.Errorf("failed to build request: %s", err)
+	if limit > MaxBodyMeasuresLimit {
+		bodyMeasureResponse.Warnings = append(bodyMeasureResponse.Warnings,
+			fmt.Sprintf("limit %d exceeds the maximum of %d and was clamped", limit, MaxBodyMeasuresLimit))
+		limit = MaxBodyMeasuresLimit
+	}
+	if limit > 0 {
+		v.Add(GetFieldName(BodyMeasuresQueryParams{}, "Limit"), strconv.Itoa(limit))
 	}
 
-	resp, err := u.HTTPClient.Do(req)
+	// Sending request to the API.
+	res, err := u.send(ctx, getBodyMeasurePath, v)
+	if u.Client.IncludePath {
+		bodyMeasureResponse.Path = res.Path
+	}
 	if err != nil {
 		return bodyMeasureResponse, err
 	}
-	defer resp.Body.Close()
+	bodyMeasureResponse.FromCache = res.FromCache
+	bodyMeasureResponse.RateLimit = res.RateLimit
+	bodyMeasureResponse.Warnings = append(bodyMeasureResponse.Warnings, res.Warnings...)
 
 	// Processing API response.
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return bodyMeasureResponse, err
-	}
 	if u.Client.SaveRawResponse {
-		bodyMeasureResponse.RawResponse = body
+		bodyMeasureResponse.RawResponse = res.Body
 	}
 
-	err = json.Unmarshal(body, &bodyMeasureResponse)
+	err = decodeResponse(res.Body, &bodyMeasureResponse)
 	if err != nil {
 		return bodyMeasureResponse, err
 	}
 	if bodyMeasureResponse.Status != status.OperationWasSuccessful {
-		return bodyMeasureResponse, fmt.Errorf("api returned an error: %s", bodyMeasureResponse.Error)
+		return bodyMeasureResponse, &APIError{Status: bodyMeasureResponse.Status, Message: bodyMeasureResponse.Error, Body: res.Body}
+	}
+	if bodyMeasureResponse.Body == nil {
+		bodyMeasureResponse.Body = &BodyMeasureRespBody{}
+	}
+	bodyMeasureResponse.Warnings = append(bodyMeasureResponse.Warnings, bodyMeasureResponse.Body.warnings...)
+	if u.Client.StrictEnums {
+		if err := bodyMeasureResponse.Body.checkEnums(); err != nil {
+			return bodyMeasureResponse, err
+		}
+	}
+
+	if params != nil && params.SortDescending {
+		bodyMeasureResponse.sortDescending()
 	}
 
 	if params != nil && params.ParseResponse {
 		bodyMeasureResponse.ParsedResponse = bodyMeasureResponse.ParseData()
 	}
 
-	return bodyMeasureResponse, nil
+	bodyMeasureResponse.Warnings = append(bodyMeasureResponse.Warnings, truncationWarnings(ctx, bodyMeasureResponse.Truncated(), bodyMeasureResponse.Body.Offset)...)
+	bodyMeasureResponse.NoData = len(bodyMeasureResponse.Body.MeasureGrps) == 0
+	return bodyMeasureResponse, noData(ctx, bodyMeasureResponse.NoData)
+
+}
+
+// GetAllBodyMeasuresCtx retrieves body measures as per GetBodyMeasuresCtx, but
+// keeps requesting further pages for as long as the API indicates there is more
+// data. Each subsequent request uses the offset returned by the server rather
+// than one computed locally. The measure groups from every page are combined
+// into the returned response; the remaining fields are those of the last page.
+//
+// If ctx is cancelled or times out after the first page, the measure groups
+// retrieved so far are returned along with ctx.Err(). The response is then
+// Truncated, and its Offset resumes from the first page not retrieved.
+func (u *User) GetAllBodyMeasuresCtx(ctx context.Context, params *BodyMeasuresQueryParams) (BodyMeasuresResp, error) {
+	p := BodyMeasuresQueryParams{}
+	if params != nil {
+		p = *params
+	}
+
+	// Sorting and parsing are applied once, to the combined result.
+	p.SortDescending = false
+	p.ParseResponse = false
+
+	pageCtx := WithRequestOptions(ctx, noDataAsError(false), paging())
+
+	var groups []MeasureGroup
+	var warnings []string
+	combine := func(r BodyMeasuresResp) BodyMeasuresResp {
+		r.Body.MeasureGrps = groups
+		r.Warnings = warnings
+		if params != nil && params.SortDescending {
+			r.sortDescending()
+		}
+		if params != nil && params.ParseResponse {
+			r.ParsedResponse = r.ParseData()
+		}
+		r.NoData = len(groups) == 0
+		return r
+	}
+
+	var page, last BodyMeasuresResp
+	err := followOffsets(p.Offset, func(offset *int) (bool, int, error) {
+		p.Offset = offset
+		var err error
+		page, err = u.GetBodyMeasuresCtx(pageCtx, &p)
+		if err != nil {
+			return false, 0, err
+		}
+
+		groups = append(groups, page.Body.MeasureGrps...)
+		warnings = append(warnings, page.Warnings...)
+		last = page
+		return page.Body.More != 0, page.Body.Offset, nil
+	})
+	if err != nil {
+		if ctx.Err() != nil && last.Body != nil {
+			return combine(last), ctx.Err()
+		}
+		return page, err
+	}
 
+	page = combine(page)
+	return page, noData(ctx, page.NoData)
 }
 
 // GetSleepMeasures is the same as GetSleepMeasuresCtx but doesn't require a context to be provided.
@@ -603,52 +1139,54 @@ func (u *User) GetSleepMeasuresCtx(ctx context.Context, params *SleepMeasuresQue
 
 	// Building query params
 	v := url.Values{}
-	v.Add("action", "get")
+	v.Add("action", string(ActionGetSleep))
 
 	// Params are required for this api call. To be consident we handle empty params and build
 	// one with sensible defaults if needed.
 	if params == nil {
 		params = &SleepMeasuresQueryParam{}
-		params.StartDate = time.Now()
-		params.EndDate = time.Now().AddDate(0, 0, -1)
+		now := u.Client.now()
+		params.StartDate = now.AddDate(0, 0, -1)
+		params.EndDate = now
 	}
 
 	v.Add(GetFieldName(*params, "StartDate"), strconv.FormatInt(params.StartDate.Unix(), 10))
 	v.Add(GetFieldName(*params, "EndDate"), strconv.FormatInt(params.EndDate.Unix(), 10))
+	if params.LastUpdate != nil {
+		v.Add(GetFieldName(*params, "LastUpdate"), strconv.FormatInt(params.LastUpdate.Unix(), 10))
+	}
 
 	// Sending request to the API.
-	path := fmt.Sprintf("%s?%s", getSleepMeasureURL, v.Encode())
+	res, err := u.send(ctx, getSleepMeasurePath, v)
 	if u.Client.IncludePath {
-		sleepMeasureRepsonse.Path = path
-	}
-
-	req, err := http.NewRequest("GET", path, nil)
-	req = req.WithContext(ctx)
-	if err != nil {
-		return sleepMeasureRepsonse, fmt.Errorf("failed to build request: %s", err)
+		sleepMeasureRepsonse.Path = res.Path
 	}
-
-	resp, err := u.HTTPClient.Do(req)
 	if err != nil {
 		return sleepMeasureRepsonse, err
 	}
-	defer resp.Body.Close()
+	sleepMeasureRepsonse.FromCache = res.FromCache
+	sleepMeasureRepsonse.RateLimit = res.RateLimit
+	sleepMeasureRepsonse.Warnings = append(sleepMeasureRepsonse.Warnings, res.Warnings...)
 
 	// Processing API response.
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return sleepMeasureRepsonse, err
-	}
 	if u.Client.SaveRawResponse {
-		sleepMeasureRepsonse.RawResponse = body
+		sleepMeasureRepsonse.RawResponse = res.Body
 	}
 
-	err = json.Unmarshal(body, &sleepMeasureRepsonse)
+	err = decodeResponse(res.Body, &sleepMeasureRepsonse)
 	if err != nil {
 		return sleepMeasureRepsonse, err
 	}
 	if sleepMeasureRepsonse.Status != status.OperationWasSuccessful {
-		return sleepMeasureRepsonse, fmt.Errorf("api returned an error: %s", sleepMeasureRepsonse.Error)
+		return sleepMeasureRepsonse, &APIError{Status: sleepMeasureRepsonse.Status, Message: sleepMeasureRepsonse.Error, Body: res.Body}
+	}
+	if sleepMeasureRepsonse.Body == nil {
+		sleepMeasureRepsonse.Body = &SleepMeasuresRespBody{}
+	}
+	if u.Client.StrictEnums {
+		if err := sleepMeasureRepsonse.Body.checkEnums(); err != nil {
+			return sleepMeasureRepsonse, err
+		}
 	}
 
 	// Parse dates
@@ -662,7 +1200,8 @@ func (u *User) GetSleepMeasuresCtx(ctx context.Context, params *SleepMeasuresQue
 		}
 	}
 
-	return sleepMeasureRepsonse, nil
+	sleepMeasureRepsonse.NoData = len(sleepMeasureRepsonse.Body.Series) == 0
+	return sleepMeasureRepsonse, noData(ctx, sleepMeasureRepsonse.NoData)
 }
 
 // GetSleepSummary is the same as GetSleepSummaryCtx but doesn't require a context to be provided.
@@ -679,55 +1218,65 @@ func (u *User) GetSleepSummaryCtx(ctx context.Context, params *SleepSummaryQuery
 
 	// Building query params
 	v := url.Values{}
-	v.Add("action", "getsummary")
+	v.Add("action", string(ActionGetSleepSummary))
 
 	// Params are required for this api call. To be consident we handle empty params and build
 	// one with sensible defaults if needed.
 	if params == nil {
 		params = &SleepSummaryQueryParam{}
-		t1 := time.Now()
-		t2 := time.Now().AddDate(0, 0, -1)
+		t2 := u.Client.today()
+		t1 := t2.AddDate(0, 0, -1)
 		params.StartDateYMD = &t1
 		params.EndDateYMD = &t2
 	}
 
 	// Although the API currently says the type is a UNIX time stamp the reality is it's a date string.
-	v.Add(GetFieldName(*params, "StartDateYMD"), params.StartDateYMD.Format("2006-01-02"))
-	v.Add(GetFieldName(*params, "EndDateYMD"), params.EndDateYMD.Format("2006-01-02"))
+	if params.StartDateYMD != nil {
+		v.Add(GetFieldName(*params, "StartDateYMD"), params.StartDateYMD.Format("2006-01-02"))
+	}
+	if params.EndDateYMD != nil {
+		v.Add(GetFieldName(*params, "EndDateYMD"), params.EndDateYMD.Format("2006-01-02"))
+	}
+	if params.LastUpdate != nil {
+		v.Add(GetFieldName(*params, "LastUpdate"), strconv.FormatInt(*params.LastUpdate, 10))
+	}
+	if params.Offset != nil {
+		v.Add(GetFieldName(*params, "Offset"), strconv.Itoa(*params.Offset))
+	}
+	if len(params.DataFields) > 0 {
+		fields, err := joinDataFields("sleep summary", params.DataFields)
+		if err != nil {
+			return sleepSummaryResponse, err
+		}
+		v.Add(GetFieldName(*params, "DataFields"), fields)
+	}
 
 	// Sending request to the API.
-	path := fmt.Sprintf("%s?%s", getSleepSummaryURL, v.Encode())
+	res, err := u.send(ctx, getSleepSummaryPath, v)
 	if u.Client.IncludePath {
-		sleepSummaryResponse.Path = path
-	}
-
-	req, err := http.NewRequest("GET", path, nil)
-	req = req.WithContext(ctx)
-	if err != nil {
-		return sleepSummaryResponse, fmt.Errorf("failed to build request: %s", err)
+		sleepSummaryResponse.Path = res.Path
 	}
-
-	resp, err := u.HTTPClient.Do(req)
 	if err != nil {
 		return sleepSummaryResponse, err
 	}
-	defer resp.Body.Close()
+	sleepSummaryResponse.FromCache = res.FromCache
+	sleepSummaryResponse.RateLimit = res.RateLimit
+	sleepSummaryResponse.Warnings = append(sleepSummaryResponse.Warnings, res.Warnings...)
 
 	// Processing API response.
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return sleepSummaryResponse, err
-	}
 	if u.Client.SaveRawResponse {
-		sleepSummaryResponse.RawResponse = body
+		sleepSummaryResponse.RawResponse = res.Body
 	}
 
-	err = json.Unmarshal(body, &sleepSummaryResponse)
+	err = decodeResponse(res.Body, &sleepSummaryResponse)
 	if err != nil {
 		return sleepSummaryResponse, err
 	}
 	if sleepSummaryResponse.Status != status.OperationWasSuccessful {
-		return sleepSummaryResponse, fmt.Errorf("api returned an error: %s", sleepSummaryResponse.Error)
+		return sleepSummaryResponse, &APIError{Status: sleepSummaryResponse.Status, Message: sleepSummaryResponse.Error, Body: res.Body}
+	}
+	if sleepSummaryResponse.Body == nil {
+		sleepSummaryResponse.Body = &SleepSummaryBody{}
 	}
 
 	// Parse all the date fields.
@@ -741,10 +1290,7 @@ func (u *User) GetSleepSummaryCtx(ctx context.Context, params *SleepSummaryQuery
 			sleepSummaryResponse.Body.Series[i].EndDateParsed = &endDate
 
 			// Parse the goofy YYYY-MM-DD plus location date.
-			location, err := time.LoadLocation(sleepSummaryResponse.Body.Series[i].TimeZone)
-			if err != nil {
-				return sleepSummaryResponse, err
-			}
+			location := u.Client.location(sleepSummaryResponse.Body.Series[i].TimeZone)
 
 			t, err := time.Parse("2006-01-02", sleepSummaryResponse.Body.Series[i].Date)
 			if err != nil {
@@ -756,10 +1302,67 @@ func (u *User) GetSleepSummaryCtx(ctx context.Context, params *SleepSummaryQuery
 		}
 	}
 
-	return sleepSummaryResponse, nil
+	sleepSummaryResponse.Warnings = append(sleepSummaryResponse.Warnings, truncationWarnings(ctx, sleepSummaryResponse.Truncated(), sleepSummaryResponse.Body.Offset)...)
+	sleepSummaryResponse.NoData = len(sleepSummaryResponse.Body.Series) == 0
+	return sleepSummaryResponse, noData(ctx, sleepSummaryResponse.NoData)
 
 }
 
+// GetAllSleepSummaryCtx retrieves sleep summaries as per GetSleepSummaryCtx,
+// but keeps requesting further pages for as long as the API indicates there is
+// more data, so long ranges aren't silently truncated. Each subsequent request
+// uses the offset returned by the server. The series from every page are
+// combined into the returned response; the remaining fields are those of the
+// last page.
+//
+// If ctx is cancelled or times out after the first page, the series retrieved
+// so far are returned along with ctx.Err(). The response is then Truncated,
+// and its Body.Offset resumes from the first page not retrieved.
+func (u *User) GetAllSleepSummaryCtx(ctx context.Context, params *SleepSummaryQueryParam) (SleepSummaryResp, error) {
+	p := SleepSummaryQueryParam{}
+	if params != nil {
+		p = *params
+	} else {
+		t2 := u.Client.today()
+		t1 := t2.AddDate(0, 0, -1)
+		p.StartDateYMD = &t1
+		p.EndDateYMD = &t2
+	}
+
+	pageCtx := WithRequestOptions(ctx, noDataAsError(false), paging())
+
+	var series []SleepSummary
+	var warnings []string
+	var page, last SleepSummaryResp
+	err := followOffsets(p.Offset, func(offset *int) (bool, int, error) {
+		p.Offset = offset
+		var err error
+		page, err = u.GetSleepSummaryCtx(pageCtx, &p)
+		if err != nil {
+			return false, 0, err
+		}
+
+		series = append(series, page.Body.Series...)
+		warnings = append(warnings, page.Warnings...)
+		last = page
+		return page.Body.More, page.Body.Offset, nil
+	})
+	if err != nil {
+		if ctx.Err() != nil && last.Body != nil {
+			last.Body.Series = series
+			last.Warnings = warnings
+			last.NoData = len(series) == 0
+			return last, ctx.Err()
+		}
+		return page, err
+	}
+
+	page.Body.Series = series
+	page.Warnings = warnings
+	page.NoData = len(series) == 0
+	return page, noData(ctx, page.NoData)
+}
+
 // CreateNotification is the same as CreateNotificationCtx but doesn't require a context to be provided.
 func (u *User) CreateNotification(params *CreateNotificationParam) (CreateNotificationResp, error) {
 	ctx, cancel := u.Client.getContext()
@@ -778,45 +1381,35 @@ func (u *User) CreateNotificationCtx(ctx context.Context, params *CreateNotifica
 
 	// Building query params.
 	v := url.Values{}
-	v.Add("action", "subscribe")
+	v.Add("action", string(ActionSubscribe))
 
 	v.Add(GetFieldName(*params, "CallbackURL"), params.CallbackURL.String())
 	v.Add(GetFieldName(*params, "Comment"), params.Comment)
 	v.Add(GetFieldName(*params, "Appli"), strconv.Itoa(params.Appli))
 
 	// Sending request to the API.
-	path := fmt.Sprintf("%s?%s", createNotficationURL, v.Encode())
+	res, err := u.send(ctx, createNotficationPath, v)
 	if u.Client.IncludePath {
-		createNotificationResponse.Path = path
+		createNotificationResponse.Path = res.Path
 	}
-
-	req, err := http.NewRequest("GET", path, nil)
-	req = req.WithContext(ctx)
-	if err != nil {
-		return createNotificationResponse, fmt.Errorf("failed to build request: %s", err)
-	}
-
-	resp, err := u.HTTPClient.Do(req)
 	if err != nil {
 		return createNotificationResponse, err
 	}
-	defer resp.Body.Close()
+	createNotificationResponse.FromCache = res.FromCache
+	createNotificationResponse.RateLimit = res.RateLimit
+	createNotificationResponse.Warnings = append(createNotificationResponse.Warnings, res.Warnings...)
 
 	// Processing API response.
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return createNotificationResponse, err
-	}
 	if u.Client.SaveRawResponse {
-		createNotificationResponse.RawResponse = body
+		createNotificationResponse.RawResponse = res.Body
 	}
 
-	err = json.Unmarshal(body, &createNotificationResponse)
+	err = decodeResponse(res.Body, &createNotificationResponse)
 	if err != nil {
 		return createNotificationResponse, err
 	}
 	if createNotificationResponse.Status != status.OperationWasSuccessful {
-		return createNotificationResponse, fmt.Errorf("api returned an error: %s", createNotificationResponse.Error)
+		return createNotificationResponse, &APIError{Status: createNotificationResponse.Status, Message: createNotificationResponse.Error, Body: res.Body}
 	}
 
 	return createNotificationResponse, nil
@@ -835,7 +1428,7 @@ func (u *User) ListNotificationsCtx(ctx context.Context, params *ListNotificatio
 
 	// Building query params.
 	v := url.Values{}
-	v.Add("action", "list")
+	v.Add("action", string(ActionListNotifications))
 
 	if params != nil {
 		if params.Appli != nil {
@@ -844,48 +1437,54 @@ func (u *User) ListNotificationsCtx(ctx context.Context, params *ListNotificatio
 	}
 
 	// Sending request to the API.
-	path := fmt.Sprintf("%s?%s", listNotificationsURL, v.Encode())
+	res, err := u.send(ctx, listNotificationsPath, v)
 	if u.Client.IncludePath {
-		listNotificationResponse.Path = path
+		listNotificationResponse.Path = res.Path
 	}
-	req, err := http.NewRequest("GET", path, nil)
-	req = req.WithContext(ctx)
-	if err != nil {
-		return listNotificationResponse, fmt.Errorf("failed to build request: %s", err)
-	}
-
-	resp, err := u.HTTPClient.Do(req)
 	if err != nil {
 		return listNotificationResponse, err
 	}
-	defer resp.Body.Close()
+	listNotificationResponse.FromCache = res.FromCache
+	listNotificationResponse.RateLimit = res.RateLimit
+	listNotificationResponse.Warnings = append(listNotificationResponse.Warnings, res.Warnings...)
 
 	// Processing API response.
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return listNotificationResponse, err
-	}
 	if u.Client.SaveRawResponse {
-		listNotificationResponse.RawResponse = body
+		listNotificationResponse.RawResponse = res.Body
 	}
 
-	err = json.Unmarshal(body, &listNotificationResponse)
+	err = decodeResponse(res.Body, &listNotificationResponse)
 	if err != nil {
 		return listNotificationResponse, err
 	}
 	if listNotificationResponse.Status != status.OperationWasSuccessful {
-		return listNotificationResponse, fmt.Errorf("api returned error: %s", listNotificationResponse.Error)
+		return listNotificationResponse, &APIError{Status: listNotificationResponse.Status, Message: listNotificationResponse.Error, Body: res.Body}
+	}
+	if listNotificationResponse.Body == nil {
+		listNotificationResponse.Body = &ListNotificationsRespBody{}
+	}
+
+	// Filter to the requested appli, in case the API returned others.
+	if params != nil && params.Appli != nil {
+		profiles := listNotificationResponse.Body.Profiles[:0]
+		for _, p := range listNotificationResponse.Body.Profiles {
+			if p.Appli == *params.Appli {
+				profiles = append(profiles, p)
+			}
+		}
+		listNotificationResponse.Body.Profiles = profiles
 	}
 
 	// Parse dates
 	if listNotificationResponse.Body != nil {
 		for i := range listNotificationResponse.Body.Profiles {
-			d := time.Unix(listNotificationResponse.Body.Profiles[0].Expires, 0)
+			d := time.Unix(listNotificationResponse.Body.Profiles[i].Expires, 0)
 			listNotificationResponse.Body.Profiles[i].ExpiresParsed = &d
 		}
 	}
 
-	return listNotificationResponse, nil
+	listNotificationResponse.NoData = len(listNotificationResponse.Body.Profiles) == 0
+	return listNotificationResponse, noData(ctx, listNotificationResponse.NoData)
 }
 
 // GetNotificationInformation is the same as GetNotificationInformationCtx but doesn't require a context to be provided.
@@ -901,7 +1500,7 @@ func (u *User) GetNotificationInformationCtx(ctx context.Context, params *Notifi
 
 	// Building query params.
 	v := url.Values{}
-	v.Add("action", "get")
+	v.Add("action", string(ActionGetNotification))
 
 	if params == nil {
 		params = &NotificationInfoParam{}
@@ -911,45 +1510,35 @@ func (u *User) GetNotificationInformationCtx(ctx context.Context, params *Notifi
 	v.Add(GetFieldName(*params, "Appli"), strconv.Itoa(*params.Appli))
 
 	// Sending reqeust to the API.
-	path := fmt.Sprintf("%s?%s", getNotificationInformationURL, v.Encode())
+	res, err := u.send(ctx, getNotificationInformationPath, v)
 	if u.Client.IncludePath {
-		notificationInfoResponse.Path = path
+		notificationInfoResponse.Path = res.Path
 	}
-
-	req, err := http.NewRequest("GET", path, nil)
-	req = req.WithContext(ctx)
-	if err != nil {
-		return notificationInfoResponse, fmt.Errorf("failed to build request: %s", err)
-	}
-
-	resp, err := u.HTTPClient.Do(req)
 	if err != nil {
 		return notificationInfoResponse, err
 	}
-	defer resp.Body.Close()
+	notificationInfoResponse.FromCache = res.FromCache
+	notificationInfoResponse.RateLimit = res.RateLimit
+	notificationInfoResponse.Warnings = append(notificationInfoResponse.Warnings, res.Warnings...)
 
 	// Processing API response.
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return notificationInfoResponse, err
-	}
 	if u.Client.SaveRawResponse {
-		notificationInfoResponse.RawResponse = body
+		notificationInfoResponse.RawResponse = res.Body
 	}
 
-	err = json.Unmarshal(body, &notificationInfoResponse)
+	err = decodeResponse(res.Body, &notificationInfoResponse)
 	if err != nil {
 		return notificationInfoResponse, err
 	}
 	if notificationInfoResponse.Status != status.OperationWasSuccessful {
-		return notificationInfoResponse, fmt.Errorf("api returned an error: %s", notificationInfoResponse.Error)
+		return notificationInfoResponse, &APIError{Status: notificationInfoResponse.Status, Message: notificationInfoResponse.Error, Body: res.Body}
 	}
 
 	// Parse dates
 	if notificationInfoResponse.Body != nil {
 		d := time.Unix(notificationInfoResponse.Body.Expires, 0)
 		notificationInfoResponse.Body.ExpiresParsed = &d
-
+		notificationInfoResponse.Body.now = u.Client.now
 	}
 
 	return notificationInfoResponse, nil
@@ -968,7 +1557,7 @@ func (u *User) RevokeNotificationCtx(ctx context.Context, params *RevokeNotifica
 
 	// Building query params.
 	v := url.Values{}
-	v.Add("action", "revoke")
+	v.Add("action", string(ActionRevokeNotification))
 
 	if params == nil {
 		params = &RevokeNotificationParam{}
@@ -978,40 +1567,87 @@ func (u *User) RevokeNotificationCtx(ctx context.Context, params *RevokeNotifica
 	v.Add(GetFieldName(*params, "Appli"), strconv.Itoa(*params.Appli))
 
 	// Sending request to the API.
-	path := fmt.Sprintf("%s?%s", revokeNotificationURL, v.Encode())
+	res, err := u.send(ctx, revokeNotificationPath, v)
 	if u.Client.IncludePath {
-		revokeResponse.Path = path
+		revokeResponse.Path = res.Path
 	}
-
-	req, err := http.NewRequest("GET", path, nil)
-	req = req.WithContext(ctx)
 	if err != nil {
-		return revokeResponse, fmt.Errorf("failed to build request: %s", err)
+		return revokeResponse, err
 	}
+	revokeResponse.FromCache = res.FromCache
+	revokeResponse.RateLimit = res.RateLimit
+	revokeResponse.Warnings = append(revokeResponse.Warnings, res.Warnings...)
 
-	resp, err := u.HTTPClient.Do(req)
+	// Processing API response.
+	if u.Client.SaveRawResponse {
+		revokeResponse.RawResponse = res.Body
+	}
+
+	err = decodeResponse(res.Body, &revokeResponse)
 	if err != nil {
 		return revokeResponse, err
 	}
-	defer resp.Body.Close()
+	if revokeResponse.Status != status.OperationWasSuccessful {
+		return revokeResponse, &APIError{Status: revokeResponse.Status, Message: revokeResponse.Error, Body: res.Body}
+	}
 
-	// Processing API response.
-	body, err := ioutil.ReadAll(resp.Body)
+	return revokeResponse, nil
+
+}
+
+// GetDevices is the same as GetDevicesCtx but doesn't require a context to be provided.
+func (u *User) GetDevices() (GetDevicesResp, error) {
+	ctx, cancel := u.Client.getContext()
+	defer cancel()
+	return u.GetDevicesCtx(ctx)
+}
+
+// GetDevicesCtx lists the devices linked to the user's account.
+func (u *User) GetDevicesCtx(ctx context.Context) (GetDevicesResp, error) {
+	devicesResponse := GetDevicesResp{}
+
+	// Building query params.
+	v := url.Values{}
+	v.Add("action", string(ActionGetDevices))
+
+	// Sending request to the API.
+	res, err := u.send(ctx, getDevicesPath, v)
+	if u.Client.IncludePath {
+		devicesResponse.Path = res.Path
+	}
 	if err != nil {
-		return revokeResponse, err
+		return devicesResponse, err
 	}
+	devicesResponse.FromCache = res.FromCache
+	devicesResponse.RateLimit = res.RateLimit
+	devicesResponse.Warnings = append(devicesResponse.Warnings, res.Warnings...)
+
+	// Processing API response.
 	if u.Client.SaveRawResponse {
-		revokeResponse.RawResponse = body
+		devicesResponse.RawResponse = res.Body
 	}
 
-	err = json.Unmarshal(body, &revokeResponse)
+	err = decodeResponse(res.Body, &devicesResponse)
 	if err != nil {
-		return revokeResponse, err
+		return devicesResponse, err
 	}
-	if revokeResponse.Status != status.OperationWasSuccessful {
-		return revokeResponse, fmt.Errorf("api returned an error: %s", revokeResponse.Error)
+	if devicesResponse.Status != status.OperationWasSuccessful {
+		return devicesResponse, &APIError{Status: devicesResponse.Status, Message: devicesResponse.Error, Body: res.Body}
+	}
+	if devicesResponse.Body == nil {
+		devicesResponse.Body = &GetDevicesRespBody{}
 	}
 
-	return revokeResponse, nil
+	// Parse dates
+	for i := range devicesResponse.Body.Devices {
+		device := &devicesResponse.Body.Devices[i]
+		if device.LastSessionDate == 0 {
+			continue
+		}
+		d := time.Unix(device.LastSessionDate, 0).In(u.Client.location(device.Timezone))
+		device.LastSessionDateParsed = &d
+	}
 
+	devicesResponse.NoData = len(devicesResponse.Body.Devices) == 0
+	return devicesResponse, noData(ctx, devicesResponse.NoData)
 }
@@ -6,13 +6,17 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"os"
 	"os/exec"
+	"strconv"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/BurntSushi/toml"
+	"github.com/asymmetricia/withings/enum/devtype"
 	"github.com/asymmetricia/withings/enum/status"
 	"github.com/stretchr/testify/require"
 )
@@ -234,3 +238,298 @@ func TestListNotifications(t *testing.T) {
 		t.Fatalf("failed to get body measurements with api error %d => %v", m.Status, m.Status.String())
 	}
 }
+
+func TestDefaultDatesUseClientClock(t *testing.T) {
+	var queries []url.Values
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		require.NoError(t, req.ParseForm())
+		queries = append(queries, req.Form)
+		rw.Header().Set("Date", time.Now().Add(-10*24*time.Hour).UTC().Format(http.TimeFormat))
+		fmt.Fprint(rw, `{"status":0,"body":{}}`)
+	})
+	u.Client.DefaultTimezone = time.UTC
+	atomic.StoreInt64(&u.Client.state.skew, int64(-10*24*time.Hour))
+
+	now := time.Now().Add(-10 * 24 * time.Hour).UTC()
+	today, yesterday := now.Format("2006-01-02"), now.AddDate(0, 0, -1).Format("2006-01-02")
+
+	_, err := u.GetActivityMeasures(nil)
+	require.NoError(t, err)
+	_, err = u.GetActivityMeasures(&ActivityMeasuresQueryParam{})
+	require.NoError(t, err)
+	_, err = u.GetSleepSummary(nil)
+	require.NoError(t, err)
+	for _, q := range queries {
+		require.Equal(t, yesterday, q.Get("startdateymd"))
+		require.Equal(t, today, q.Get("enddateymd"))
+	}
+
+	queries = nil
+	_, err = u.GetSleepMeasures(nil)
+	require.NoError(t, err)
+	start, err := strconv.ParseInt(queries[0].Get("startdate"), 10, 64)
+	require.NoError(t, err)
+	end, err := strconv.ParseInt(queries[0].Get("enddate"), 10, 64)
+	require.NoError(t, err)
+	require.WithinDuration(t, now, time.Unix(end, 0), time.Minute)
+	require.Equal(t, int64(24*60*60), end-start)
+}
+
+func TestEmptyWindows(t *testing.T) {
+	bodies := map[string]string{
+		"empty body":   `{"status":0,"body":[]}`,
+		"missing body": `{"status":0}`,
+		"empty series": `{"status":0,"body":{"series":[],"measuregrps":[],"activities":[],"profiles":[]}}`,
+	}
+
+	endpoints := map[string]func(u *User) (bool, error){
+		"GetIntradayActivity": func(u *User) (bool, error) {
+			r, err := u.GetIntradayActivity(nil)
+			return r.NoData && r.Body != nil && len(r.Body.Series) == 0, err
+		},
+		"GetActivityMeasures": func(u *User) (bool, error) {
+			r, err := u.GetActivityMeasures(nil)
+			return r.NoData && r.Body != nil && len(r.Body.Activities) == 0, err
+		},
+		"GetWorkouts": func(u *User) (bool, error) {
+			r, err := u.GetWorkouts(nil)
+			return r.NoData && r.Body != nil && len(r.Body.Series) == 0, err
+		},
+		"GetBodyMeasures": func(u *User) (bool, error) {
+			r, err := u.GetBodyMeasures(&BodyMeasuresQueryParams{ParseResponse: true})
+			return r.NoData && r.Body != nil && len(r.Body.MeasureGrps) == 0 && len(r.ParsedResponse.Weights) == 0, err
+		},
+		"GetSleepMeasures": func(u *User) (bool, error) {
+			r, err := u.GetSleepMeasures(nil)
+			return r.NoData && r.Body != nil && len(r.Body.Series) == 0, err
+		},
+		"GetSleepSummary": func(u *User) (bool, error) {
+			r, err := u.GetSleepSummary(nil)
+			return r.NoData && r.Body != nil && len(r.Body.Series) == 0, err
+		},
+		"ListNotifications": func(u *User) (bool, error) {
+			r, err := u.ListNotifications(nil)
+			return r.NoData && r.Body != nil && len(r.Body.Profiles) == 0, err
+		},
+	}
+
+	for bodyName, body := range bodies {
+		body := body
+		u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+			fmt.Fprint(rw, body)
+		})
+
+		for name, call := range endpoints {
+			t.Run(bodyName+"/"+name, func(t *testing.T) {
+				empty, err := call(u)
+				require.NoError(t, err)
+				require.True(t, empty)
+			})
+		}
+	}
+}
+
+func TestNoDataAsError(t *testing.T) {
+	body := `{"status":0,"body":{"series":[]}}`
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(rw, body)
+	})
+
+	r, err := u.GetSleepSummaryCtx(context.Background(), nil)
+	require.NoError(t, err)
+	require.True(t, r.NoData)
+
+	ctx := WithRequestOptions(context.Background(), NoDataAsError())
+	r, err = u.GetSleepSummaryCtx(ctx, nil)
+	require.ErrorIs(t, err, ErrNoData)
+	require.True(t, r.NoData)
+	require.NotNil(t, r.Body)
+
+	all, err := u.GetAllSleepSummaryCtx(ctx, nil)
+	require.ErrorIs(t, err, ErrNoData)
+	require.True(t, all.NoData)
+
+	body = `{"status":0,"body":{"series":[{"date":"2021-03-15"}]}}`
+	r, err = u.GetSleepSummaryCtx(ctx, nil)
+	require.NoError(t, err)
+	require.False(t, r.NoData)
+}
+
+func TestTruncatedResponses(t *testing.T) {
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		require.NoError(t, req.ParseForm())
+		switch {
+		case req.Form.Get("action") == "getmeas":
+			fmt.Fprint(rw, `{"status":0,"body":{"more":1,"offset":20,"measuregrps":[]}}`)
+		case req.Form.Get("offset") == "20":
+			fmt.Fprint(rw, `{"status":0,"body":{"more":false,"series":[],"activities":[]}}`)
+		default:
+			fmt.Fprint(rw, `{"status":0,"body":{"more":true,"offset":20,"series":[],"activities":[]}}`)
+		}
+	})
+
+	measures, err := u.GetBodyMeasures(nil)
+	require.NoError(t, err)
+	require.True(t, measures.Truncated())
+	require.Len(t, measures.Warnings, 1)
+	require.Contains(t, measures.Warnings[0], "truncated")
+
+	activity, err := u.GetActivityMeasures(nil)
+	require.NoError(t, err)
+	require.True(t, activity.Truncated())
+	require.Len(t, activity.Warnings, 1)
+
+	workouts, err := u.GetWorkouts(nil)
+	require.NoError(t, err)
+	require.True(t, workouts.Truncated())
+	require.Len(t, workouts.Warnings, 1)
+
+	sleep, err := u.GetSleepSummary(nil)
+	require.NoError(t, err)
+	require.True(t, sleep.Truncated())
+	require.Len(t, sleep.Warnings, 1)
+
+	// Helpers that follow the continuation don't warn about their pages.
+	all, err := u.GetAllSleepSummaryCtx(context.Background(), nil)
+	require.NoError(t, err)
+	require.False(t, all.Truncated())
+	require.Empty(t, all.Warnings)
+}
+
+func TestBaseURL(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		paths = append(paths, req.URL.Path)
+		require.Equal(t, "Bearer access-token", req.Header.Get("Authorization"))
+		fmt.Fprint(rw, `{"status":0,"body":{"measuregrps":[{"grpid":1,"date":1636300800,"measures":[{"value":72345,"type":1,"unit":-3}]}]}}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	c := NewClient("client-id", "client-secret", "http://localhost:8888")
+	c.BaseURL = srv.URL + "/"
+	u, err := c.NewUserFromAccessToken(context.Background(), "access-token", time.Now().Add(time.Hour), "refresh-token")
+	require.NoError(t, err)
+
+	r, err := u.GetBodyMeasures(nil)
+	require.NoError(t, err)
+	require.Len(t, r.Body.MeasureGrps, 1)
+	require.Equal(t, []string{"/measure"}, paths)
+}
+
+func TestGetDevicesParsing(t *testing.T) {
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		require.NoError(t, req.ParseForm())
+		require.Equal(t, "/v2/user", req.URL.Path)
+		require.Equal(t, "getdevice", req.Form.Get("action"))
+		fmt.Fprint(rw, `{"status":0,"body":{"devices":[`+
+			`{"type":"Scale","model":"Body Cardio","model_id":6,"battery":"high","deviceid":"abc","timezone":"Europe/Paris","last_session_date":1594159644},`+
+			`{"type":16,"model":"Pulse","battery":"low","deviceid":"def","last_session_date":"1594159000"},`+
+			`{"type":"Thermometer","model":"Thermo","deviceid":"ghi"},`+
+			`{"model":"Mystery","deviceid":"jkl"}]}}`)
+	})
+
+	r, err := u.GetDevices()
+	require.NoError(t, err)
+	require.False(t, r.NoData)
+	require.Len(t, r.Body.Devices, 4)
+
+	scale := r.Body.Devices[0]
+	require.NotNil(t, scale.Type)
+	require.Equal(t, devtype.BodyScale, *scale.Type)
+	require.Equal(t, "Scale", scale.TypeName)
+	require.Equal(t, "Body Cardio", scale.Model)
+	require.Equal(t, 6, scale.ModelID)
+	require.Equal(t, "high", scale.Battery)
+	require.Equal(t, "abc", scale.DeviceID)
+	require.NotNil(t, scale.LastSessionDateParsed)
+	require.Equal(t, int64(1594159644), scale.LastSessionDateParsed.Unix())
+	require.Equal(t, "Europe/Paris", scale.LastSessionDateParsed.Location().String())
+
+	tracker := r.Body.Devices[1]
+	require.NotNil(t, tracker.Type)
+	require.Equal(t, devtype.ActivityTracker, *tracker.Type)
+	require.Empty(t, tracker.TypeName)
+	require.Equal(t, int64(1594159000), tracker.LastSessionDate)
+
+	unknown := r.Body.Devices[2]
+	require.Equal(t, "Thermometer", unknown.TypeName)
+	require.Nil(t, unknown.Type)
+	require.Nil(t, unknown.LastSessionDateParsed)
+
+	require.Nil(t, r.Body.Devices[3].Type)
+}
+
+func TestGetHeartListParsing(t *testing.T) {
+	start := time.Unix(1594000000, 0)
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		require.NoError(t, req.ParseForm())
+		require.Equal(t, "/v2/heart", req.URL.Path)
+		require.Equal(t, "list", req.Form.Get("action"))
+		require.Equal(t, "1594000000", req.Form.Get("startdate"))
+		require.Equal(t, "3", req.Form.Get("offset"))
+		fmt.Fprint(rw, `{"status":0,"body":{"series":[`+
+			`{"deviceid":"abc","model":91,"ecg":{"signalid":12345,"afib":2},"heart_rate":72,"timestamp":1594159644,"timezone":"Europe/Paris"},`+
+			`{"deviceid":"def","model":44,"bloodpressure":{"diastole":80,"systole":120},"heart_rate":65,"timestamp":1594160000}`+
+			`],"more":true,"offset":5}}`)
+	})
+
+	offset := 3
+	r, err := u.GetHeartList(&HeartListQueryParam{StartDate: &start, Offset: &offset})
+	require.NoError(t, err)
+	require.True(t, r.Truncated())
+	require.Equal(t, 5, r.Body.Offset)
+	require.Len(t, r.Body.Series, 2)
+
+	ecg := r.Body.Series[0]
+	require.Equal(t, int64(12345), ecg.ECG.SignalID)
+	require.Equal(t, AFibInconclusive, ecg.ECG.AFib)
+	require.Equal(t, 72, ecg.HeartRate)
+	require.Equal(t, int64(1594159644), ecg.TimestampParsed.Unix())
+	require.Equal(t, "Europe/Paris", ecg.TimestampParsed.Location().String())
+
+	bp := r.Body.Series[1]
+	require.Zero(t, bp.ECG.SignalID)
+	require.Equal(t, HeartBloodPressure{Diastole: 80, Systole: 120}, bp.BloodPressure)
+}
+
+func TestGetHeartSignal(t *testing.T) {
+	calls := 0
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		calls++
+		require.NoError(t, req.ParseForm())
+		require.Equal(t, "/v2/heart", req.URL.Path)
+		require.Equal(t, "get", req.Form.Get("action"))
+		switch req.Form.Get("signalid") {
+		case "1":
+			if calls == 1 {
+				fmt.Fprint(rw, `{"status":601,"error":"Too many requests"}`)
+				return
+			}
+			fmt.Fprint(rw, `{"status":0,"body":{"signal":[-250,0,1500],"sampling_frequency":500,"wearposition":1}}`)
+		case "2":
+			fmt.Fprint(rw, `{"status":0,"body":[]}`)
+		}
+	})
+	u.Client.MaxRetries = 1
+	u.Client.BackoffBase = time.Millisecond
+
+	r, err := u.GetHeartSignal(1)
+	require.NoError(t, err)
+	require.Equal(t, 2, calls)
+	require.Empty(t, r.Error)
+	require.Nil(t, r.RawResponse)
+	require.Equal(t, []int{-250, 0, 1500}, r.Body.Signal)
+	require.Equal(t, []float64{-0.25, 0, 1.5}, r.Body.Millivolts())
+	require.Equal(t, 2*time.Millisecond, r.Body.SampleInterval())
+	require.Equal(t, 1, r.Body.WearPosition)
+
+	r, err = u.GetHeartSignal(2)
+	require.NoError(t, err)
+	require.Empty(t, r.Body.Signal)
+
+	u.Client.SaveRawResponse = true
+	r, err = u.GetHeartSignal(1)
+	require.NoError(t, err)
+	require.Equal(t, []int{-250, 0, 1500}, r.Body.Signal)
+	require.Contains(t, string(r.RawResponse), `"signal"`)
+}
@@ -0,0 +1,39 @@
+package withings
+
+import "github.com/asymmetricia/withings/enum/workoutfield"
+
+// Values of Workout.Attrib, which tells how a workout was recorded.
+const (
+	// WorkoutAttribDevice marks a workout recorded by a device, such as one
+	// started manually on a watch.
+	WorkoutAttribDevice = 0
+	// WorkoutAttribManual marks a workout entered manually in the app.
+	WorkoutAttribManual = 2
+	// WorkoutAttribManualAtCreation marks a workout entered manually while
+	// creating the user account.
+	WorkoutAttribManualAtCreation = 4
+	// WorkoutAttribDetected marks a workout the device detected on its own
+	// and the user then confirmed.
+	WorkoutAttribDetected = 7
+)
+
+// IsAutoDetected reports whether the device detected the workout on its own,
+// rather than it being started or entered by the user. Detected workouts are
+// usually less precise about when they started and ended.
+func (w Workout) IsAutoDetected() bool {
+	return w.Attrib == WorkoutAttribDetected
+}
+
+// IsManualEntry reports whether the workout was entered by hand rather than
+// recorded by a device, in which case its data holds no sensor metrics.
+func (w Workout) IsManualEntry() bool {
+	return w.Attrib == WorkoutAttribManual || w.Attrib == WorkoutAttribManualAtCreation
+}
+
+// Field returns the value of metric f of the workout, and whether the API
+// returned it. Metrics are only returned if they were requested with
+// WorkoutsQueryParam.DataFields, or were chosen by the API if none were.
+func (w Workout) Field(f workoutfield.Field) (float64, bool) {
+	v, ok := w.Data[string(f)]
+	return v, ok
+}
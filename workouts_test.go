@@ -0,0 +1,105 @@
+package withings
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asymmetricia/withings/enum/workoutfield"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetRecentWorkoutsExpandsWindow(t *testing.T) {
+	var windows []int
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		start, err := time.Parse("2006-01-02", req.URL.Query().Get("startdateymd"))
+		require.NoError(t, err)
+		end, err := time.Parse("2006-01-02", req.URL.Query().Get("enddateymd"))
+		require.NoError(t, err)
+		days := int(end.Sub(start).Hours()/24 + 0.5)
+		windows = append(windows, days)
+
+		// One workout every 20 days, oldest first.
+		var series []string
+		for d := days / 20 * 20; d >= 0; d -= 20 {
+			date := end.AddDate(0, 0, -d)
+			series = append(series, fmt.Sprintf(`{"id":%d,"startdate":%d,"enddate":%d,"date":%q}`,
+				d, date.Unix(), date.Unix()+3600, date.Format("2006-01-02")))
+		}
+		fmt.Fprintf(rw, `{"status":0,"body":{"series":[%s]}}`, strings.Join(series, ","))
+	})
+
+	workouts, err := u.GetRecentWorkoutsCtx(context.Background(), 4)
+	require.NoError(t, err)
+	require.Equal(t, []int{30, 60}, windows)
+
+	require.Len(t, workouts, 4)
+	for i, w := range workouts {
+		require.Equal(t, i*20, w.ID)
+	}
+}
+
+func TestGetRecentWorkoutsCapsWindow(t *testing.T) {
+	var calls int
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		calls++
+		fmt.Fprint(rw, `{"status":0,"body":{"series":[]}}`)
+	})
+
+	workouts, err := u.GetRecentWorkoutsCtx(context.Background(), 10)
+	require.NoError(t, err)
+	require.Empty(t, workouts)
+	// 30, 60, 120, 240 and finally 365 days.
+	require.Equal(t, 5, calls)
+}
+
+func TestWorkoutAttrib(t *testing.T) {
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(rw, `{"status":0,"body":{"series":[
+			{"id":1,"category":1,"attrib":7,"startdate":1609524000,"enddate":1609527600,"date":"2021-01-01"},
+			{"id":2,"category":1,"attrib":0,"startdate":1609610400,"enddate":1609614000,"date":"2021-01-02"},
+			{"id":3,"category":1,"attrib":2,"startdate":1609696800,"enddate":1609700400,"date":"2021-01-03"}
+		]}}`)
+	})
+
+	r, err := u.GetWorkouts(nil)
+	require.NoError(t, err)
+	require.Len(t, r.Body.Series, 3)
+
+	detected, started, entered := r.Body.Series[0], r.Body.Series[1], r.Body.Series[2]
+	require.True(t, detected.IsAutoDetected())
+	require.False(t, detected.IsManualEntry())
+	require.False(t, started.IsAutoDetected())
+	require.False(t, started.IsManualEntry())
+	require.False(t, entered.IsAutoDetected())
+	require.True(t, entered.IsManualEntry())
+}
+
+func TestWorkoutDataFields(t *testing.T) {
+	var calls int
+	u := newTestUser(t, func(rw http.ResponseWriter, req *http.Request) {
+		calls++
+		require.NoError(t, req.ParseForm())
+		require.Equal(t, "hr_average,calories", req.Form.Get("data_fields"))
+		fmt.Fprint(rw, `{"status":0,"body":{"series":[
+			{"id":1,"startdate":1609524000,"enddate":1609527600,"date":"2021-01-01","data":{"hr_average":128,"calories":350}}
+		]}}`)
+	})
+
+	r, err := u.GetWorkouts(&WorkoutsQueryParam{
+		DataFields: []workoutfield.Field{workoutfield.HRAverage, workoutfield.Calories},
+	})
+	require.NoError(t, err)
+	hr, ok := r.Body.Series[0].Field(workoutfield.HRAverage)
+	require.True(t, ok)
+	require.Equal(t, float64(128), hr)
+	_, ok = r.Body.Series[0].Field(workoutfield.HRMax)
+	require.False(t, ok)
+
+	_, err = u.GetWorkouts(&WorkoutsQueryParam{DataFields: []workoutfield.Field{"hr_avg"}})
+	require.Error(t, err)
+	require.Equal(t, 1, calls)
+}